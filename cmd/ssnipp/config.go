@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+)
+
+// config holds the settings read from the configuration file.
+type config struct {
+	server string
+	token  string
+}
+
+// defaultConfigPath returns the path of the configuration file in the user configuration
+// directory, such as ~/.config/ssnipp/config on Linux.
+func defaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "ssnipp", "config"), nil
+}
+
+// loadConfig reads the configuration file at the given path. Like the .env file of the
+// server, it contains KEY=value lines:
+//
+//	SERVER=https://ssnipp.com
+//	TOKEN=ssnipp_...
+func loadConfig(path string) (config, error) {
+	values, err := godotenv.Read(path)
+	if err != nil {
+		return config{}, fmt.Errorf("reading config file: %w", err)
+	}
+
+	cfg := config{
+		server: values["SERVER"],
+		token:  values["TOKEN"],
+	}
+
+	if cfg.server == "" {
+		return config{}, errors.New("config file: SERVER is not set")
+	}
+
+	if cfg.token == "" {
+		return config{}, errors.New("config file: TOKEN is not set")
+	}
+
+	return cfg, nil
+}
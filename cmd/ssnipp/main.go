@@ -0,0 +1,235 @@
+// Command ssnipp is a command-line client for an ssnipp server. It creates snippets from
+// files or the standard input, printing their URL, and prints the content of snippets:
+//
+//	ssnipp [--lang language] [--visibility visibility] [--expires expiration] [file ...]
+//	ssnipp get [--file number] <slug or URL>
+//
+// Snippets made of several files are printed one file after the other, each under a
+// header with its name, unless a single file is picked by its number.
+//
+// The server URL and a personal API token are read from a configuration file, by default
+// ssnipp/config in the user configuration directory, with SERVER and TOKEN lines.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"ssnipp.com/internal/client"
+	"ssnipp.com/internal/languages"
+)
+
+// The main() function, which is the entry point for the command.
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ssnipp: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command with the given arguments, reading snippets from stdin when no
+// files are given, and writing its output to stdout.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) > 0 && args[0] == "get" {
+		return runGet(args[1:], stdout)
+	}
+
+	return runCreate(args, stdin, stdout)
+}
+
+// runCreate creates a snippet from each file, or from stdin, and prints their URLs.
+func runCreate(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("ssnipp", flag.ContinueOnError)
+	configPath := flags.String("config", "", "Path of the configuration file")
+	lang := flags.String("lang", "", "Language of the snippets, guessed from the file extensions or contents if empty")
+	visibility := flags.String("visibility", "", "Visibility of the snippets: unlisted, public or private")
+	expires := flags.String("expires", "", "Expiration of the snippets, such as 1h, 1d or never")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient(*configPath)
+	if err != nil {
+		return err
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	for _, file := range files {
+		content, err := readContent(file, stdin)
+		if err != nil {
+			return err
+		}
+
+		language := *lang
+		if language == "" {
+			language = guessLanguage(file)
+		}
+
+		snippet, err := c.Create(client.NewSnippet{
+			Content:    content,
+			Language:   language,
+			Expires:    *expires,
+			Visibility: *visibility,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(stdout, c.ViewURL(snippet.Slug))
+	}
+
+	return nil
+}
+
+// runGet prints the content of the snippet identified by its slug or URL.
+func runGet(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("ssnipp get", flag.ContinueOnError)
+	configPath := flags.String("config", "", "Path of the configuration file")
+	file := flags.Int("file", 0, "Number of the file to print, from 1 for the main file (all files by default)")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 || *file < 0 {
+		return errors.New("usage: ssnipp get [--file number] <slug or URL>")
+	}
+
+	c, err := newClient(*configPath)
+	if err != nil {
+		return err
+	}
+
+	snippet, err := c.Get(slugFromArg(flags.Arg(0)))
+	if err != nil {
+		return err
+	}
+
+	// The key of encrypted snippets never reaches the server, so only the browser can
+	// show them.
+	if snippet.Encrypted {
+		return errors.New("the snippet is encrypted, open its full link in a browser to read it")
+	}
+
+	return writeSnippet(stdout, snippet, *file)
+}
+
+// writeSnippet writes the content of the file of the snippet with the given number, from
+// 1 for the main file, or of all its files if the number is 0. When there are several,
+// each file is preceded by a header with its name, or its number if it has none.
+func writeSnippet(w io.Writer, snippet client.Snippet, number int) error {
+	files := append([]client.File{{Filename: snippet.Filename, Content: snippet.Content}}, snippet.Files...)
+
+	if number > len(files) {
+		return fmt.Errorf("the snippet has no file %d, only %d", number, len(files))
+	}
+	if number > 0 {
+		_, err := io.WriteString(w, files[number-1].Content)
+		return err
+	}
+	if len(files) == 1 {
+		_, err := io.WriteString(w, snippet.Content)
+		return err
+	}
+
+	for i, f := range files {
+		name := f.Filename
+		if name == "" {
+			name = fmt.Sprintf("File %d", i+1)
+		}
+
+		// Separate the files by a blank line, as head and tail do
+		separator := ""
+		if i > 0 {
+			separator = "\n"
+		}
+
+		_, err := fmt.Fprintf(w, "%s==> %s <==\n%s", separator, name, f.Content)
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(f.Content, "\n") {
+			_, err = io.WriteString(w, "\n")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// newClient returns an API client for the server and token of the configuration file at
+// the given path, or at the default path if empty.
+func newClient(configPath string) (*client.Client, error) {
+	if configPath == "" {
+		var err error
+
+		configPath, err = defaultConfigPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client.Client{
+		BaseURL:    cfg.server,
+		Token:      cfg.token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// readContent reads the content of a snippet from the named file, or from stdin if the
+// name is "-".
+func readContent(name string, stdin io.Reader) (string, error) {
+	if name == "-" {
+		b, err := io.ReadAll(stdin)
+		return string(b), err
+	}
+
+	b, err := os.ReadFile(name)
+	return string(b), err
+}
+
+// guessLanguage guesses the language of a file from its name or extension, using the
+// same language registry as the server. It returns "auto", leaving the server to detect
+// the language from the content, if the file isn't recognised.
+func guessLanguage(name string) string {
+	language, ok := languages.Default.ForFilename(name)
+	if !ok {
+		return "auto"
+	}
+
+	return language.Key
+}
+
+// slugFromArg returns the slug of a snippet given either its slug or the URL of one of
+// its pages, such as https://ssnipp.com/view/aBcD3fGh1jKl.
+func slugFromArg(arg string) string {
+	u, err := url.Parse(arg)
+	if err != nil || u.Scheme == "" {
+		return arg
+	}
+
+	return path.Base(u.Path)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ssnipp.com/internal/assert"
+	"ssnipp.com/internal/client"
+)
+
+// TestGuessLanguage tests that languages are guessed from file extensions.
+func TestGuessLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{name: "Go", file: "main.go", want: "go"},
+		{name: "Upper case extension", file: "INDEX.HTML", want: "html"},
+		{name: "Path", file: "scripts/deploy.sh", want: "bash"},
+		{name: "File name", file: "docker/Dockerfile", want: "dockerfile"},
+		{name: "Unknown extension", file: "notes.bak", want: "auto"},
+		{name: "Stdin", file: "-", want: "auto"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, guessLanguage(tt.file), tt.want)
+		})
+	}
+}
+
+// TestSlugFromArg tests that snippets can be identified by their slug or URL.
+func TestSlugFromArg(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  string
+		want string
+	}{
+		{name: "Slug", arg: "aBcD3fGh1jKl", want: "aBcD3fGh1jKl"},
+		{name: "View URL", arg: "https://ssnipp.com/view/aBcD3fGh1jKl", want: "aBcD3fGh1jKl"},
+		{name: "Raw URL", arg: "https://ssnipp.com/raw/aBcD3fGh1jKl", want: "aBcD3fGh1jKl"},
+		{name: "URL with fragment", arg: "https://ssnipp.com/view/aBcD3fGh1jKl#key", want: "aBcD3fGh1jKl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, slugFromArg(tt.arg), tt.want)
+		})
+	}
+}
+
+// TestWriteSnippet tests that all the files of a snippet are printed under their name,
+// unless a single file is picked.
+func TestWriteSnippet(t *testing.T) {
+	snippet := client.Snippet{
+		Filename: "Dockerfile",
+		Content:  "FROM golang:1.22",
+		Files: []client.File{
+			{Filename: "build.sh", Content: "docker build -t app .\n"},
+			{Content: "Run build.sh from the project root."},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		snippet client.Snippet
+		number  int
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "All files",
+			snippet: snippet,
+			want:    "==> Dockerfile <==\nFROM golang:1.22\n\n==> build.sh <==\ndocker build -t app .\n\n==> File 3 <==\nRun build.sh from the project root.\n",
+		},
+		{name: "Main file", snippet: snippet, number: 1, want: "FROM golang:1.22"},
+		{name: "Additional file", snippet: snippet, number: 2, want: "docker build -t app .\n"},
+		{name: "Non-existent file", snippet: snippet, number: 4, wantErr: true},
+		{name: "Single file", snippet: client.Snippet{Content: "console.log();"}, want: "console.log();"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			err := writeSnippet(&b, tt.snippet, tt.number)
+
+			assert.Equal(t, err != nil, tt.wantErr)
+			assert.Equal(t, b.String(), tt.want)
+		})
+	}
+}
+
+// TestLoadConfig tests that the server and token are read from the configuration file.
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(dir, t.Name())
+		err := os.MkdirAll(filepath.Dir(path), 0o700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		cfg, err := loadConfig(write(t, "SERVER=https://ssnipp.com\nTOKEN=ssnipp_abc\n"))
+		assert.NilError(t, err)
+		assert.Equal(t, cfg.server, "https://ssnipp.com")
+		assert.Equal(t, cfg.token, "ssnipp_abc")
+	})
+
+	t.Run("Missing token", func(t *testing.T) {
+		_, err := loadConfig(write(t, "SERVER=https://ssnipp.com\n"))
+		assert.Equal(t, err.Error(), "config file: TOKEN is not set")
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := loadConfig(filepath.Join(dir, "missing"))
+		assert.Equal(t, err != nil, true)
+	})
+}
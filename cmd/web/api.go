@@ -0,0 +1,371 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"ssnipp.com/internal/models"
+	"ssnipp.com/internal/validator"
+)
+
+// maxJSONBytes limits the size of the JSON request bodies accepted by the API.
+const maxJSONBytes = 1_048_576
+
+// envelope wraps the data sent in JSON responses, so that every response is a JSON object
+// with named top-level keys.
+type envelope map[string]any
+
+// apiSnippet is the JSON representation of a snippet in the API. Internal fields, such
+// as the sequential ID and the password hash, are left out. The author is only known for
+// snippets retrieved from the database.
+type apiSnippet struct {
+	Slug              string     `json:"slug"`
+	Content           string     `json:"content"`
+	Language          string     `json:"language"`
+	Author            string     `json:"author,omitempty"`
+	Created           time.Time  `json:"created"`
+	Expires           *time.Time `json:"expires,omitempty"`
+	BurnAfterRead     bool       `json:"burn_after_read"`
+	Visibility        string     `json:"visibility"`
+	PasswordProtected bool       `json:"password_protected"`
+	Encrypted         bool       `json:"encrypted"`
+	Tags              []string   `json:"tags"`
+	Title             string     `json:"title"`
+	Description       string     `json:"description"`
+	Filename          string     `json:"filename"`
+	Files             []apiFile  `json:"files"`
+}
+
+// apiFile is the JSON representation of an additional file of a snippet. The main file
+// is described by the content, language and filename of the snippet itself.
+type apiFile struct {
+	Filename string `json:"filename"`
+	Language string `json:"language"`
+	Content  string `json:"content"`
+}
+
+// newAPISnippet converts a snippet to its JSON representation.
+func newAPISnippet(s models.Snippet) apiSnippet {
+	snippet := apiSnippet{
+		Slug:              s.Slug,
+		Content:           s.Content,
+		Language:          s.Language,
+		Author:            s.UserName,
+		Created:           s.Created,
+		BurnAfterRead:     s.BurnAfterRead,
+		Visibility:        s.Visibility,
+		PasswordProtected: s.HasPassword(),
+		Encrypted:         s.Encrypted,
+		Tags:              s.Tags,
+		Title:             s.Title,
+		Description:       s.Description,
+		Filename:          s.Filename,
+		Files:             []apiFile{},
+	}
+
+	for _, f := range s.Files {
+		snippet.Files = append(snippet.Files, apiFile{Filename: f.Filename, Language: f.Language, Content: f.Content})
+	}
+
+	// Snippets without tags have an empty list of tags.
+	if snippet.Tags == nil {
+		snippet.Tags = []string{}
+	}
+
+	// Snippets which never expire have no expiry date.
+	if !s.Expires.IsZero() {
+		snippet.Expires = &s.Expires
+	}
+
+	return snippet
+}
+
+// writeJSON encodes the data as JSON and sends it with the given status code and headers.
+func (app *application) writeJSON(w http.ResponseWriter, status int, data envelope, headers http.Header) error {
+	js, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	// Append a newline to make the response easier to read in terminals.
+	js = append(js, '\n')
+
+	for key, value := range headers {
+		w.Header()[key] = value
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(js)
+
+	return nil
+}
+
+// readJSON decodes a JSON request body into the destination. It returns an error with a
+// message suitable for the client if the body isn't a single valid JSON object matching
+// the destination.
+func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	// Limit the size of the request body.
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err != nil {
+		var syntaxError *json.SyntaxError
+		var unmarshalTypeError *json.UnmarshalTypeError
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &syntaxError), errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("body contains badly-formed JSON")
+		case errors.As(err, &unmarshalTypeError):
+			return fmt.Errorf("body contains an incorrect JSON type for field %q", unmarshalTypeError.Field)
+		case errors.Is(err, io.EOF):
+			return errors.New("body must not be empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return fmt.Errorf("body contains unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+		case errors.As(err, &maxBytesError):
+			return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		default:
+			return err
+		}
+	}
+
+	// Make sure the body only contains a single JSON value.
+	err = dec.Decode(&struct{}{})
+	if !errors.Is(err, io.EOF) {
+		return errors.New("body must only contain a single JSON value")
+	}
+
+	return nil
+}
+
+// hasJSONContentType reports whether the request body is declared as JSON. Requiring it
+// also protects the API from cross-site form submissions, as browsers only send other
+// content types cross-site after a CORS preflight request, which the API doesn't allow.
+func hasJSONContentType(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// errorJSON sends a JSON error response with the given status code and message.
+func (app *application) errorJSON(w http.ResponseWriter, r *http.Request, status int, message string) {
+	err := app.writeJSON(w, status, envelope{"error": message}, nil)
+	if err != nil {
+		app.logger.Error(err.Error(), "method", r.Method, "uri", r.URL.RequestURI())
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// serverErrorJSON logs the detailed error message like serverError, then sends a generic
+// JSON error response to the client.
+func (app *application) serverErrorJSON(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Error(err.Error(), "method", r.Method, "uri", r.URL.RequestURI())
+	app.errorJSON(w, r, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+}
+
+// notFoundJSON sends a 404 Not Found JSON error response.
+func (app *application) notFoundJSON(w http.ResponseWriter, r *http.Request) {
+	app.errorJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+}
+
+// failedValidationJSON sends a 422 Unprocessable Entity JSON error response, with the
+// field and non-field errors recorded by the validator.
+func (app *application) failedValidationJSON(w http.ResponseWriter, r *http.Request, v validator.Validator) {
+	data := envelope{"error": "the submitted snippet is invalid"}
+
+	if len(v.FieldErrors) > 0 {
+		data["field_errors"] = v.FieldErrors
+	}
+	if len(v.NonFieldErrors) > 0 {
+		data["non_field_errors"] = v.NonFieldErrors
+	}
+
+	err := app.writeJSON(w, http.StatusUnprocessableEntity, data, nil)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+// API snippet view handler
+func (app *application) apiSnippetView(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the snippet identified in the URL
+	snippet, err := app.snippetFromSlug(r, r.PathValue("slug"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFoundJSON(w, r)
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	// Password-protected snippets must have been unlocked in the session
+	if !app.isUnlocked(r, snippet) {
+		app.errorJSON(w, r, http.StatusForbidden, "this snippet is protected by a password")
+		return
+	}
+
+	// Prepare the response before burning the snippet, which must be the last step before
+	// sending it, so that a snippet is never burned by a request which fails
+	data := envelope{"snippet": newAPISnippet(snippet)}
+
+	// Burn-after-reading snippets are deleted as soon as someone other than their owner reads them
+	err = app.burnOnRead(r, snippet)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFoundJSON(w, r)
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+// API snippet list handler, listing the snippets of the authenticated user
+func (app *application) apiSnippetList(w http.ResponseWriter, r *http.Request) {
+	// Get the requested page number from the query string
+	page, err := readPositiveInt(r, "page", 1)
+	if err != nil {
+		app.errorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p := newPagination(page, snippetsPerPage, 0)
+
+	// Retrieve the page of snippets owned by the authenticated user
+	snippets, total, err := app.snippets.ListByUser(app.authenticatedUserID(r), p.PageSize, p.Offset())
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	p.TotalRecords = total
+
+	list := make([]apiSnippet, len(snippets))
+	for i, s := range snippets {
+		list[i] = newAPISnippet(s)
+	}
+
+	data := envelope{
+		"snippets": list,
+		"pagination": envelope{
+			"page":          p.Page,
+			"page_size":     p.PageSize,
+			"total_pages":   p.TotalPages(),
+			"total_records": p.TotalRecords,
+		},
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+// API snippet creation handler
+func (app *application) apiSnippetCreate(w http.ResponseWriter, r *http.Request) {
+	if !hasJSONContentType(r) {
+		app.errorJSON(w, r, http.StatusUnsupportedMediaType, "the request body must be JSON")
+		return
+	}
+
+	// Start from the same defaults as the home page form
+	form := snippetCreateForm{
+		Language:   "plaintext",
+		Expires:    "never",
+		Visibility: models.VisibilityUnlisted,
+	}
+
+	// Decode the request body
+	err := app.readJSON(w, r, &form)
+	if err != nil {
+		app.errorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Additional files default to plain text, like the main file
+	for i := range form.Files {
+		if form.Files[i].Language == "" {
+			form.Files[i].Language = "plaintext"
+		}
+	}
+
+	// Validate the snippet like the HTML form
+	form.validateCreate()
+	if !form.Valid() {
+		app.failedValidationJSON(w, r, form.Validator)
+		return
+	}
+
+	created := time.Now()
+
+	snippet, err := form.newSnippet(app.authenticatedUserID(r), created)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	// Insert the snippet into the database, owned by the authenticated user
+	err = app.snippets.Insert(&snippet)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	snippet.Created = created
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/api/v1/snippets/%s", snippet.Slug))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"snippet": newAPISnippet(snippet)}, headers)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+// API snippet deletion handler
+func (app *application) apiSnippetDelete(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the snippet identified in the URL
+	snippet, err := app.snippetFromSlug(r, r.PathValue("slug"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFoundJSON(w, r)
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	// Only the owner of the snippet can delete it
+	if !app.isOwner(r, snippet) {
+		app.errorJSON(w, r, http.StatusForbidden, "you can only delete your own snippets")
+		return
+	}
+
+	// Mark the snippet as deleted, it will be purged after the retention period
+	err = app.snippets.Delete(snippet.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFoundJSON(w, r)
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"ssnipp.com/internal/assert"
+	"ssnipp.com/internal/models/mocks"
+)
+
+// TestAPISnippetView tests the GET /api/v1/snippets/{slug} endpoint.
+func TestAPISnippetView(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid slug",
+			urlPath:  "/api/v1/snippets/aBcD3fGh1jKl",
+			wantCode: http.StatusOK,
+			wantBody: `"content": "console.log();"`,
+		},
+		{
+			name:     "Non-existent slug",
+			urlPath:  "/api/v1/snippets/xXxXxXxXxXxX",
+			wantCode: http.StatusNotFound,
+			wantBody: `"error": "the requested resource could not be found"`,
+		},
+		{
+			name:     "Private",
+			urlPath:  "/api/v1/snippets/pR1v4t3sN1pP",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Password protected",
+			urlPath:  "/api/v1/snippets/pR0t3ct3dSn1",
+			wantCode: http.StatusForbidden,
+			wantBody: `"error": "this snippet is protected by a password"`,
+		},
+		{
+			name:     "Encrypted",
+			urlPath:  "/api/v1/snippets/eNcRyPt3dSn1",
+			wantCode: http.StatusOK,
+			wantBody: `"encrypted": true`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, headers, body := ts.request(t, http.MethodGet, tt.urlPath, "")
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Content-Type"), "application/json")
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+// TestAPISnippetList tests the GET /api/v1/snippets endpoint.
+func TestAPISnippetList(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, _, body := ts.request(t, http.MethodGet, "/api/v1/snippets", "")
+
+		// Assert that API clients get an error rather than a redirect to the login page.
+		assert.Equal(t, code, http.StatusUnauthorized)
+		assert.StringContains(t, body, `"error": "you must be authenticated to access this resource"`)
+	})
+
+	// Log in as the mocked user who owns the mocked snippets.
+	ts.login(t, "alice@example.com")
+
+	t.Run("Authenticated", func(t *testing.T) {
+		code, _, body := ts.request(t, http.MethodGet, "/api/v1/snippets", "")
+		assert.Equal(t, code, http.StatusOK)
+
+		var rs struct {
+			Snippets []struct {
+				Slug string `json:"slug"`
+			} `json:"snippets"`
+			Pagination struct {
+				TotalRecords int `json:"total_records"`
+			} `json:"pagination"`
+		}
+
+		err := json.Unmarshal([]byte(body), &rs)
+		assert.NilError(t, err)
+
+		// Assert that the snippets of the user are listed, along with the pagination.
+		assert.Equal(t, len(rs.Snippets), 2)
+		assert.Equal(t, rs.Snippets[0].Slug, "aBcD3fGh1jKl")
+		assert.Equal(t, rs.Pagination.TotalRecords, 2)
+	})
+
+	t.Run("Invalid page", func(t *testing.T) {
+		code, _, _ := ts.request(t, http.MethodGet, "/api/v1/snippets?page=0", "")
+		assert.Equal(t, code, http.StatusBadRequest)
+	})
+}
+
+// TestAPISnippetCreate tests the POST /api/v1/snippets endpoint with various request bodies.
+func TestAPISnippetCreate(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, _, _ := ts.request(t, http.MethodPost, "/api/v1/snippets", `{"content": "console.log();"}`)
+		assert.Equal(t, code, http.StatusUnauthorized)
+	})
+
+	// Log in as a mocked user. No CSRF token is needed by the API.
+	ts.login(t, "alice@example.com")
+
+	tests := []struct {
+		name         string
+		body         string
+		wantCode     int
+		wantBody     string
+		wantLocation string
+	}{
+		{
+			name:         "Valid snippet",
+			body:         `{"content": "console.log();", "language": "javascript"}`,
+			wantCode:     http.StatusCreated,
+			wantBody:     `"slug": "nEwSn1pP3t0o"`,
+			wantLocation: "/api/v1/snippets/nEwSn1pP3t0o",
+		},
+		{
+			name:         "Default language",
+			body:         `{"content": "hello"}`,
+			wantCode:     http.StatusCreated,
+			wantBody:     `"language": "plaintext"`,
+			wantLocation: "/api/v1/snippets/nEwSn1pP3t0o",
+		},
+		{
+			name:         "Multiple files",
+			body:         `{"content": "FROM golang:1.22", "filename": "Dockerfile", "files": [{"filename": "build.sh", "content": "docker build -t app ."}]}`,
+			wantCode:     http.StatusCreated,
+			wantBody:     `"filename": "build.sh"`,
+			wantLocation: "/api/v1/snippets/nEwSn1pP3t0o",
+		},
+		{
+			name:     "Invalid fields",
+			body:     `{"content": "", "language": "latin"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"language": "Choose a valid language"`,
+		},
+		{
+			name:     "Unknown field",
+			body:     `{"content": "hello", "colour": "red"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `body contains unknown field \"colour\"`,
+		},
+		{
+			name:     "Badly-formed JSON",
+			body:     `{"content": "hello"`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `"error": "body contains badly-formed JSON"`,
+		},
+		{
+			name:     "Wrong type",
+			body:     `{"content": 42}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `incorrect JSON type for field \"content\"`,
+		},
+		{
+			name:     "Several values",
+			body:     `{"content": "hello"}{}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, headers, body := ts.request(t, http.MethodPost, "/api/v1/snippets", tt.body)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("Form content type", func(t *testing.T) {
+		rs, err := ts.Client().Post(ts.URL+"/api/v1/snippets", "application/x-www-form-urlencoded", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs.Body.Close()
+
+		// Assert that requests which could be cross-site form submissions are rejected.
+		assert.Equal(t, rs.StatusCode, http.StatusUnsupportedMediaType)
+	})
+}
+
+// TestAPISnippetDelete tests the DELETE /api/v1/snippets/{slug} endpoint for the owner of
+// a snippet and other users.
+func TestAPISnippetDelete(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name     string
+		email    string
+		urlPath  string
+		wantCode int
+	}{
+		{
+			name:     "Owner",
+			email:    "alice@example.com",
+			urlPath:  "/api/v1/snippets/aBcD3fGh1jKl",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Not the owner",
+			email:    "bob@example.com",
+			urlPath:  "/api/v1/snippets/aBcD3fGh1jKl",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent slug",
+			email:    "alice@example.com",
+			urlPath:  "/api/v1/snippets/xXxXxXxXxXxX",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Unauthenticated",
+			urlPath:  "/api/v1/snippets/aBcD3fGh1jKl",
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			if tt.email != "" {
+				ts.login(t, tt.email)
+			}
+
+			code, _, _ := ts.request(t, http.MethodDelete, tt.urlPath, "")
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+// TestAPITokenAuthentication tests that the API accepts personal API tokens, and enforces
+// their scope.
+func TestAPITokenAuthentication(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		urlPath  string
+		token    string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Read token lists snippets",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/snippets",
+			token:    mocks.MockReadToken,
+			wantCode: http.StatusOK,
+			wantBody: `"slug": "pR1v4t3sN1pP"`,
+		},
+		{
+			name:     "Read token views private snippet",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/snippets/pR1v4t3sN1pP",
+			token:    mocks.MockReadToken,
+			wantCode: http.StatusOK,
+			wantBody: `"content": "DEBUG=true"`,
+		},
+		{
+			name:     "Read token creates snippet",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/snippets",
+			token:    mocks.MockReadToken,
+			body:     `{"content": "hello"}`,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Read token deletes snippet",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/snippets/aBcD3fGh1jKl",
+			token:    mocks.MockReadToken,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Write token creates snippet",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/snippets",
+			token:    mocks.MockWriteToken,
+			body:     `{"content": "hello"}`,
+			wantCode: http.StatusCreated,
+			wantBody: `"slug": "nEwSn1pP3t0o"`,
+		},
+		{
+			name:     "Write token deletes snippet",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/snippets/aBcD3fGh1jKl",
+			token:    mocks.MockWriteToken,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Invalid token",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/snippets/aBcD3fGh1jKl",
+			token:    "ssnipp_notAValidToken",
+			wantCode: http.StatusUnauthorized,
+			wantBody: `"error": "invalid or missing authentication token"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.requestWithToken(t, tt.method, tt.urlPath, tt.token, tt.body)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("Other scheme", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/snippets", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetBasicAuth("alice@example.com", "pa$$word")
+
+		rs, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs.Body.Close()
+
+		assert.Equal(t, rs.StatusCode, http.StatusUnauthorized)
+		assert.Equal(t, rs.Header.Get("WWW-Authenticate"), "Bearer")
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"ssnipp.com/internal/assert"
+	"ssnipp.com/internal/client"
+	"ssnipp.com/internal/models/mocks"
+)
+
+// TestClient tests the API client used by the ssnipp command against the application routes.
+func TestClient(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	newClient := func(token string) *client.Client {
+		return &client.Client{BaseURL: ts.URL, Token: token, HTTPClient: ts.Client()}
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		c := newClient(mocks.MockWriteToken)
+
+		snippet, err := c.Create(client.NewSnippet{Content: "console.log();", Language: "javascript"})
+		assert.NilError(t, err)
+		assert.Equal(t, snippet.Slug, "nEwSn1pP3t0o")
+		assert.Equal(t, snippet.Language, "javascript")
+		assert.Equal(t, c.ViewURL(snippet.Slug), ts.URL+"/view/nEwSn1pP3t0o")
+	})
+
+	t.Run("Create with defaults", func(t *testing.T) {
+		snippet, err := newClient(mocks.MockWriteToken).Create(client.NewSnippet{Content: "hello"})
+		assert.NilError(t, err)
+		assert.Equal(t, snippet.Language, "plaintext")
+		assert.Equal(t, snippet.Visibility, "unlisted")
+	})
+
+	t.Run("Create invalid snippet", func(t *testing.T) {
+		_, err := newClient(mocks.MockWriteToken).Create(client.NewSnippet{Content: "hello", Language: "latin"})
+
+		var apiErr *client.APIError
+		assert.Equal(t, errors.As(err, &apiErr), true)
+		assert.Equal(t, apiErr.StatusCode, http.StatusUnprocessableEntity)
+		assert.Equal(t, apiErr.FieldErrors["language"], "Choose a valid language")
+	})
+
+	t.Run("Create with read token", func(t *testing.T) {
+		_, err := newClient(mocks.MockReadToken).Create(client.NewSnippet{Content: "hello"})
+
+		var apiErr *client.APIError
+		assert.Equal(t, errors.As(err, &apiErr), true)
+		assert.Equal(t, apiErr.StatusCode, http.StatusForbidden)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		snippet, err := newClient(mocks.MockReadToken).Get("aBcD3fGh1jKl")
+		assert.NilError(t, err)
+		assert.Equal(t, snippet.Content, "console.log();")
+	})
+
+	t.Run("Get private snippet", func(t *testing.T) {
+		snippet, err := newClient(mocks.MockReadToken).Get("pR1v4t3sN1pP")
+		assert.NilError(t, err)
+		assert.Equal(t, snippet.Content, "DEBUG=true")
+	})
+
+	t.Run("Get non-existent snippet", func(t *testing.T) {
+		_, err := newClient(mocks.MockReadToken).Get("xXxXxXxXxXxX")
+		assert.Equal(t, errors.Is(err, client.ErrNotFound), true)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		_, err := newClient("ssnipp_notAValidToken").Get("aBcD3fGh1jKl")
+
+		var apiErr *client.APIError
+		assert.Equal(t, errors.As(err, &apiErr), true)
+		assert.Equal(t, apiErr.StatusCode, http.StatusUnauthorized)
+	})
+}
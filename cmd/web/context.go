@@ -4,3 +4,10 @@ type contextKey string
 
 // isAuthenticatedContextKey is a context key for the isAuthenticated value.
 const isAuthenticatedContextKey = contextKey("isAuthenticated")
+
+// authenticatedUserIDContextKey is a context key for the ID of the authenticated user.
+const authenticatedUserIDContextKey = contextKey("authenticatedUserID")
+
+// tokenScopeContextKey is a context key for the scope of the personal API token used to
+// authenticate the request, if any.
+const tokenScopeContextKey = contextKey("tokenScope")
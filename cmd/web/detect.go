@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"ssnipp.com/internal/languages"
+)
+
+// autoLanguage is the language key asking for the language of a new snippet to be
+// detected from its filename and content.
+const autoLanguage = "auto"
+
+// maxDetectBytes limits how much of the content of a snippet is looked at to detect its
+// language, as the first lines are usually enough.
+const maxDetectBytes = 16 * 1024
+
+// minDetectScore is the score a language must reach for the content to be detected as
+// written in it, so that a couple of common words don't make plain text look like code.
+const minDetectScore = 4
+
+// languageHint is a pattern suggesting that code is written in a language, weighted by
+// how specific the pattern is to that language.
+type languageHint struct {
+	rx     *regexp.Regexp
+	weight int
+}
+
+// hint returns a languageHint for the pattern, matched line by line.
+func hint(weight int, pattern string) languageHint {
+	return languageHint{rx: regexp.MustCompile("(?m)" + pattern), weight: weight}
+}
+
+// scriptHints are the hints shared by JavaScript and TypeScript.
+var scriptHints = []languageHint{
+	hint(1, `\b(const|let) \w+ = `),
+	hint(2, `\bfunction\s*\w*\s*\([^)]*\)\s*\{`),
+	hint(1, `\) => `),
+	hint(3, `\bconsole\.(log|error|warn)\(`),
+	hint(2, `\b(document|window)\.\w+`),
+	hint(2, `\brequire\(['"]`),
+	hint(3, `^import .+ from ['"]`),
+	hint(2, `===|!==`),
+	hint(1, `\bundefined\b`),
+	hint(3, `\bmodule\.exports\b`),
+	hint(2, `^export (default |const |function |class )`),
+}
+
+// cssHints are the hints shared by CSS and SCSS.
+var cssHints = []languageHint{
+	hint(2, `^\s*[.#]?[\w-]+(:{1,2}[\w-]+)?(\s*[,>+~ ]\s*[.#]?[\w-]+(:{1,2}[\w-]+)?)*\s*\{\s*$`),
+	hint(2, `^\s*[a-z-]+\s*:\s*[^;{}]+;\s*$`),
+	hint(3, `^\s*@media\b`),
+	hint(2, `\b\d+(px|em|rem|vh|vw)\b`),
+	hint(1, `#[0-9a-fA-F]{3,6}\b`),
+	hint(2, `!important`),
+}
+
+// languageHints lists the hints suggesting that code is written in each language.
+var languageHints = map[string][]languageHint{
+	"html": {
+		hint(5, `(?i)<!DOCTYPE html>`),
+		hint(2, `<(html|head|body|div|span|p|a|ul|li|table|form|input|button|script|link|meta|h[1-6])\b[^>]*>`),
+		hint(1, `</\w+>`),
+		hint(2, `<\w+[^>]*\s(class|id|href|src|style)=["']`),
+	},
+	"css": cssHints,
+	"scss": append([]languageHint{
+		hint(3, `^\s*\$[\w-]+\s*:`),
+		hint(4, `@(mixin|include|extend)\b`),
+		hint(3, `&:{1,2}[\w-]+`),
+		hint(2, `^\s*&`),
+	}, cssHints...),
+	"javascript": scriptHints,
+	"typescript": append([]languageHint{
+		hint(3, `:\s*(string|number|boolean|any|void|unknown)\b`),
+		hint(3, `^\s*(export )?interface \w+`),
+		hint(3, `^\s*(export )?type \w+ = `),
+		hint(2, `\bas (string|number|const)\b`),
+		hint(2, `\b(private|public|readonly) \w+:`),
+	}, scriptHints...),
+	"php": {
+		hint(5, `<\?php`),
+		hint(4, `\$this->`),
+		hint(3, `\bfunction \w+\(\$`),
+		hint(3, `^\s*namespace [\w\\]+;`),
+		hint(2, `^\s*echo\b.*;\s*$`),
+		hint(1, `\$\w+\s*=`),
+	},
+	"python": {
+		hint(4, `^\s*def \w+\(.*\)\s*(->\s*[\w\[\], .]+)?:\s*$`),
+		hint(3, `^\s*class \w+(\(.*\))?:\s*$`),
+		hint(4, `^if __name__ == ['"]__main__['"]:`),
+		hint(3, `^\s*from [\w.]+ import \w+`),
+		hint(1, `^import \w+(\.\w+)*\s*$`),
+		hint(2, `\bself\.\w+`),
+		hint(3, `^\s*elif .*:\s*$`),
+		hint(2, `\bNone\b`),
+		hint(2, `^\s*(for|while|if|with|try|else|except)\b.*:\s*$`),
+		hint(1, `\bprint\(`),
+	},
+	"go": {
+		hint(3, `^package \w+\s*$`),
+		hint(2, `^import \(`),
+		hint(3, `^func (\(\w+ \*?\w+\) )?\w+\(`),
+		hint(1, `:= `),
+		hint(2, `\bfmt\.\w+\(`),
+		hint(3, `\bif err != nil\b`),
+		hint(2, `^\s*defer \w+`),
+		hint(2, `^type \w+ (struct|interface) \{`),
+	},
+	"sql": {
+		hint(3, `(?i)^\s*SELECT\s+[\w*]`),
+		hint(2, `(?i)^\s*FROM \w+`),
+		hint(4, `(?i)^\s*INSERT INTO\b`),
+		hint(4, `(?i)^\s*CREATE (TABLE|INDEX|VIEW|DATABASE)\b`),
+		hint(4, `(?i)^\s*UPDATE \w+ SET\b`),
+		hint(4, `(?i)^\s*DELETE FROM\b`),
+		hint(4, `(?i)^\s*ALTER TABLE\b`),
+		hint(1, `(?i)\bWHERE\b`),
+		hint(1, `(?i)\b(INNER |LEFT |RIGHT )?JOIN\b`),
+		hint(2, `(?i)\bPRIMARY KEY\b`),
+	},
+	"bash": {
+		hint(3, `^\s*(if|while) \[\[? `),
+		hint(3, `^\s*(fi|done|esac)\s*$`),
+		hint(1, `^\s*(sudo|apt|apt-get|brew|cd|mkdir|rm|cp|mv|curl|wget|chmod|chown|export|echo|git|npm|docker|make) `),
+		hint(2, `\$\{\w+`),
+		hint(2, `\$\(`),
+		hint(2, `\|\s*(grep|awk|sed|xargs|sort|head|tail)\b`),
+		hint(2, `^\s*\w+=("|'|\$)`),
+	},
+	"xml": {
+		hint(5, `^<\?xml\b`),
+		hint(3, `\bxmlns(:\w+)?=`),
+		hint(2, `</\w+:\w+>`),
+	},
+	"c": {
+		hint(4, `^#include\s*<\w+(/\w+)*\.h>`),
+		hint(3, `\bint main\s*\(`),
+		hint(2, `\bprintf\(`),
+		hint(2, `\b(malloc|free|sizeof)\(`),
+		hint(1, `\bstruct \w+\s*\{`),
+		hint(1, `\bNULL\b`),
+		hint(1, `\w->\w`),
+	},
+	"cpp": {
+		hint(4, `^#include\s*<\w+>`),
+		hint(4, `\bstd::`),
+		hint(3, `\b(cout|cerr)\s*<<`),
+		hint(2, `^\s*using namespace \w+;`),
+		hint(3, `\btemplate\s*<`),
+		hint(2, `\bnullptr\b`),
+		hint(1, `^\s*class \w+`),
+		hint(1, `^#include\s*"`),
+	},
+	"csharp": {
+		hint(5, `^\s*using System(\.\w+)*;`),
+		hint(2, `^\s*namespace [\w.]+`),
+		hint(4, `\bConsole\.Write(Line)?\(`),
+		hint(4, `\{ get; (private )?set; \}`),
+		hint(4, `\basync Task\b`),
+		hint(1, `\bpublic (static )?(void|class|string|int)\b`),
+		hint(1, `\bvar \w+ = new\b`),
+	},
+	"java": {
+		hint(4, `^\s*package [\w.]+;`),
+		hint(3, `^\s*import (static )?[\w.]+(\.\*)?;`),
+		hint(5, `\bSystem\.out\.print`),
+		hint(5, `\bpublic static void main\(String`),
+		hint(3, `^\s*@Override\b`),
+		hint(2, `\bpublic (static )?(final )?class \w+`),
+		hint(2, `\bString\[\]`),
+	},
+	"swift": {
+		hint(5, `^import (UIKit|SwiftUI|Foundation|Combine)\b`),
+		hint(4, `\bguard let\b`),
+		hint(3, `\bif let\b`),
+		hint(2, `\bvar \w+\s*:\s*\w+`),
+		hint(2, `\bfunc \w+\(.*\)\s*(->\s*\w+)?\s*\{`),
+		hint(4, `@(IBOutlet|IBAction|State|Published|Binding|MainActor)\b`),
+		hint(3, `\bstruct \w+\s*:\s*\w+`),
+	},
+	"rust": {
+		hint(3, `\bfn \w+(<.*>)?\(`),
+		hint(4, `\blet mut\b`),
+		hint(3, `^\s*impl\b`),
+		hint(4, `\b(println|format|vec|panic)!\(`),
+		hint(3, `^\s*use \w+(::\w+)+`),
+		hint(3, `&(mut |'\w+ )?str\b`),
+		hint(3, `\bpub (fn|struct|enum)\b`),
+		hint(4, `#\[derive\(`),
+		hint(2, `\bmatch \w+ \{`),
+	},
+	"ruby": {
+		hint(3, `^\s*def \w+[?!]?(\(.*\))?\s*$`),
+		hint(2, `^\s*end\s*$`),
+		hint(3, `\bputs\b`),
+		hint(2, `^\s*require ['"]`),
+		hint(4, `\.each do\b`),
+		hint(4, `\bdo \|\w+(, ?\w+)*\|`),
+		hint(3, `:\w+ => `),
+		hint(4, `\battr_(accessor|reader|writer)\b`),
+		hint(2, `^\s*module \w+`),
+		hint(4, `\belsif\b`),
+	},
+	"perl": {
+		hint(5, `^\s*use (strict|warnings);`),
+		hint(4, `\bmy [$@%]\w+`),
+		hint(3, `\bsub \w+\s*\{`),
+		hint(2, `\$_\b`),
+		hint(3, `=~ [ms]?/`),
+		hint(4, `\bforeach my\b`),
+	},
+	"lua": {
+		hint(3, `\blocal \w+\s*=`),
+		hint(3, `~=`),
+		hint(3, `\belseif\b`),
+		hint(4, `--\[\[`),
+		hint(2, `\.\.\s*["']|["']\s*\.\.`),
+		hint(4, `\bi?pairs\(`),
+		hint(1, `^\s*end\s*$`),
+		hint(1, `\bfunction \w+([.:]\w+)?\(`),
+	},
+	"yaml": {
+		hint(4, `^---\s*$`),
+		hint(2, `^[\w-]+:\s*$`),
+		hint(2, `^\s+[\w-]+: [^{;]+$`),
+		hint(3, `^\s*- [\w-]+: `),
+		hint(1, `^\s*- [^-]`),
+	},
+	"toml": {
+		hint(4, `^\[\[?[\w.-]+\]\]?\s*$`),
+		hint(2, `^[\w-]+ = ("|'|\[|\{|true|false|\d)`),
+		hint(2, `^[\w-]+\.[\w.-]+ = `),
+	},
+	"dockerfile": {
+		hint(5, `^FROM [\w./:-]+(@\S+)?( (?i:AS) \w+)?\s*$`),
+		hint(3, `^RUN `),
+		hint(3, `^(COPY|ADD) (--\w+=\S+ )*\S+ \S+`),
+		hint(3, `^(CMD|ENTRYPOINT) \[`),
+		hint(2, `^(WORKDIR|EXPOSE|ENV|ARG|USER|VOLUME|LABEL) `),
+	},
+	"kotlin": {
+		hint(4, `^\s*fun (<.*> )?\w+\(.*\)(: [\w<>?]+)? [{=]`),
+		hint(3, `^\s*val \w+(: \w+)? = `),
+		hint(2, `^\s*var \w+: \w+`),
+		hint(4, `^\s*(data|sealed|enum|open) class \w+`),
+		hint(3, `^\s*package [\w.]+\s*$`),
+		hint(3, `\bprintln\(`),
+		hint(3, `\bwhen \(`),
+		hint(2, `\?\.|\?:`),
+	},
+	"markdown": {
+		hint(3, `^#{1,6} \S`),
+		hint(3, `^\x60\x60\x60`),
+		hint(2, `\[[^\]]+\]\([^)]+\)`),
+		hint(2, `^\s*[-*] \S`),
+		hint(1, `\*\*\S[^*]*\*\*`),
+		hint(2, `^> `),
+		hint(2, `^\|.*\|\s*$`),
+	},
+	"diff": {
+		hint(5, `^diff --git `),
+		hint(3, `^--- \S`),
+		hint(3, `^\+\+\+ \S`),
+		hint(5, `^@@ -\d+(,\d+)? \+\d+(,\d+)? @@`),
+		hint(1, `^[+-][^+-]`),
+	},
+}
+
+// interpreterLanguages maps the interpreters named in shebang lines to languages.
+var interpreterLanguages = map[string]string{
+	"bash":   "bash",
+	"sh":     "shell",
+	"zsh":    "shell",
+	"python": "python",
+	"node":   "javascript",
+	"deno":   "typescript",
+	"php":    "php",
+	"ruby":   "ruby",
+	"perl":   "perl",
+	"lua":    "lua",
+}
+
+// shebangRX matches shebang lines, capturing the name of the interpreter, such as
+// "python3" in "#!/usr/bin/env python3".
+var shebangRX = regexp.MustCompile(`^#!\s*\S*/(?:env\s+(?:-\S+\s+)*)?([\w.]+)`)
+
+// detectLanguage returns the language of a file among the supported languages, from its
+// filename if it has a known name or extension, or else from the patterns found in its
+// content. It returns "plaintext" if the language can't be told.
+func detectLanguage(filename, content string) string {
+	// Known file names and extensions settle the language
+	if language, ok := languages.Default.ForFilename(filename); ok {
+		return language.Key
+	}
+
+	trimmed := strings.TrimSpace(content)
+
+	// So do the interpreters of scripts, ignoring their version, as in "python3"
+	if m := shebangRX.FindStringSubmatch(trimmed); m != nil {
+		language, ok := interpreterLanguages[strings.TrimRight(m[1], "0123456789.")]
+		if ok && isLanguage(language) {
+			return language
+		}
+	}
+
+	// Objects and arrays which parse as JSON are JSON, unlike JavaScript code
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+
+	if len(content) > maxDetectBytes {
+		content = content[:maxDetectBytes]
+	}
+
+	// Otherwise, pick the language whose hints match best. Ties go to the language
+	// listed first, such as JavaScript over TypeScript, which shares its hints.
+	best, bestScore := "plaintext", minDetectScore-1
+	for _, language := range getLanguages() {
+		score := 0
+		for _, h := range languageHints[language.Key] {
+			if h.rx.MatchString(content) {
+				score += h.weight
+			}
+		}
+
+		if score > bestScore {
+			best, bestScore = language.Key, score
+		}
+	}
+
+	return best
+}
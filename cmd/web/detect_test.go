@@ -0,0 +1,214 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"ssnipp.com/internal/assert"
+)
+
+// TestDetectLanguage tests the detectLanguage function with a corpus of short files in
+// every supported language.
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name     string // Name of the test case.
+		filename string // Filename of the file.
+		content  string // Content of the file.
+		want     string // Expected language.
+	}{
+		{
+			name:    "Plain text",
+			content: "Remember to buy milk, eggs and bread.\nCall Alice at 5.",
+			want:    "plaintext",
+		},
+		{
+			name:    "Empty",
+			content: "",
+			want:    "plaintext",
+		},
+		{
+			name:     "Extension",
+			filename: "notes.PY",
+			content:  "Remember to buy milk.",
+			want:     "python",
+		},
+		{
+			name:     "Unknown extension",
+			filename: "notes.bak",
+			content:  "package main\n\nfunc main() {\n}",
+			want:     "go",
+		},
+		{
+			name:    "HTML",
+			content: "<!DOCTYPE html>\n<html>\n  <body>\n    <p>Hello</p>\n  </body>\n</html>",
+			want:    "html",
+		},
+		{
+			name:    "HTML fragment",
+			content: "<div class=\"card\">\n  <a href=\"/\">Home</a>\n</div>",
+			want:    "html",
+		},
+		{
+			name:    "CSS",
+			content: "body {\n  margin: 0;\n  color: #333;\n}\n\n.button:hover {\n  padding: 4px 8px;\n}",
+			want:    "css",
+		},
+		{
+			name:    "SCSS",
+			content: "$primary: #333;\n\n.button {\n  color: $primary;\n  &:hover {\n    @include shadow;\n  }\n}",
+			want:    "scss",
+		},
+		{
+			name:    "JavaScript",
+			content: "const items = document.querySelectorAll(\"li\");\nitems.forEach((item) => {\n  console.log(item.textContent);\n});",
+			want:    "javascript",
+		},
+		{
+			name:    "TypeScript",
+			content: "interface User {\n  name: string;\n  age: number;\n}\n\nexport const greet = (user: User): string => `Hi ${user.name}`;",
+			want:    "typescript",
+		},
+		{
+			name:    "JSON",
+			content: "{\n  \"name\": \"ssnipp\",\n  \"private\": true,\n  \"tags\": [\"go\"]\n}",
+			want:    "json",
+		},
+		{
+			name:    "PHP",
+			content: "<?php\n\nfunction greet($name) {\n    echo \"Hello $name\";\n}",
+			want:    "php",
+		},
+		{
+			name:    "Python",
+			content: "import os\n\ndef main():\n    for name in os.listdir(\".\"):\n        print(name)\n\nif __name__ == \"__main__\":\n    main()",
+			want:    "python",
+		},
+		{
+			name:    "Go",
+			content: "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}",
+			want:    "go",
+		},
+		{
+			name:    "SQL",
+			content: "SELECT id, title\nFROM snippets\nWHERE deleted_at IS NULL\nORDER BY created DESC;",
+			want:    "sql",
+		},
+		{
+			name:    "Bash",
+			content: "if [ -f .env ]; then\n  export $(cat .env | xargs)\nfi\necho \"Loaded ${APP_NAME}\"",
+			want:    "bash",
+		},
+		{
+			name:    "Bash shebang",
+			content: "#!/usr/bin/env bash\nls",
+			want:    "bash",
+		},
+		{
+			name:    "Shell shebang",
+			content: "#!/bin/sh\nls",
+			want:    "shell",
+		},
+		{
+			name:    "Python shebang",
+			content: "#!/usr/bin/env python3\nprint('hi')",
+			want:    "python",
+		},
+		{
+			name:    "XML",
+			content: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<note>\n  <to>Alice</to>\n</note>",
+			want:    "xml",
+		},
+		{
+			name:    "C",
+			content: "#include <stdio.h>\n\nint main(void) {\n    printf(\"hello\\n\");\n    return 0;\n}",
+			want:    "c",
+		},
+		{
+			name:    "C++",
+			content: "#include <iostream>\n\nint main() {\n    std::cout << \"hello\" << std::endl;\n}",
+			want:    "cpp",
+		},
+		{
+			name:    "C#",
+			content: "using System;\n\nclass Program {\n    static void Main() {\n        Console.WriteLine(\"hello\");\n    }\n}",
+			want:    "csharp",
+		},
+		{
+			name:    "Java",
+			content: "public class Main {\n    public static void main(String[] args) {\n        System.out.println(\"hello\");\n    }\n}",
+			want:    "java",
+		},
+		{
+			name:    "Swift",
+			content: "import Foundation\n\nfunc greet(name: String) -> String {\n    guard let first = name.first else { return \"\" }\n    return \"Hi \\(first)\"\n}",
+			want:    "swift",
+		},
+		{
+			name:    "Rust",
+			content: "fn main() {\n    let mut count = 0;\n    count += 1;\n    println!(\"{}\", count);\n}",
+			want:    "rust",
+		},
+		{
+			name:    "Ruby",
+			content: "class Greeter\n  attr_reader :name\n\n  def greet\n    [1, 2].each do |i|\n      puts \"Hi #{name}\"\n    end\n  end\nend",
+			want:    "ruby",
+		},
+		{
+			name:    "Perl",
+			content: "use strict;\nuse warnings;\n\nmy @names = ('a', 'b');\nforeach my $name (@names) {\n    print \"$name\\n\";\n}",
+			want:    "perl",
+		},
+		{
+			name:    "Lua",
+			content: "local config = {}\n\nfor key, value in pairs(config) do\n  if value ~= nil then\n    print(key .. \"=\" .. value)\n  end\nend",
+			want:    "lua",
+		},
+		{
+			name:     "File name",
+			filename: "docker/Dockerfile",
+			content:  "Remember to buy milk.",
+			want:     "dockerfile",
+		},
+		{
+			name:    "YAML",
+			content: "name: CI\non:\n  push:\n    branches: [main]\njobs:\n  test:\n    steps:\n      - uses: actions/checkout@v4\n      - run: go test ./...",
+			want:    "yaml",
+		},
+		{
+			name:    "TOML",
+			content: "[package]\nname = \"ssnipp\"\nversion = \"0.1.0\"\n\n[dependencies]\nserde = { version = \"1\" }",
+			want:    "toml",
+		},
+		{
+			name:    "Dockerfile",
+			content: "FROM golang:1.22 AS build\nWORKDIR /src\nCOPY . .\nRUN go build -o /web ./cmd/web\n\nFROM alpine\nCOPY --from=build /web /web\nCMD [\"/web\"]",
+			want:    "dockerfile",
+		},
+		{
+			name:    "Kotlin",
+			content: "data class User(val name: String)\n\nfun greet(user: User?): String {\n    val name = user?.name ?: \"stranger\"\n    return \"Hi $name\"\n}",
+			want:    "kotlin",
+		},
+		{
+			name:    "Markdown",
+			content: "# ssnipp\n\nShare **snippets** with [links](https://ssnipp.com).\n\n- Fast\n- Simple",
+			want:    "markdown",
+		},
+		{
+			name:    "Diff",
+			content: "--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,3 @@\n package main\n-import \"fmt\"\n+import \"log\"",
+			want:    "diff",
+		},
+		{
+			name:    "Long JSON",
+			content: "[" + strings.Repeat(`"abcdefghij",`, 2000) + `"end"]`,
+			want:    "json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, detectLanguage(tt.filename, tt.content), tt.want)
+		})
+	}
+}
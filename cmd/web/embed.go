@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"ssnipp.com/internal/models"
+	"ssnipp.com/internal/validator"
+)
+
+// embedThemes are the color themes of embedded snippets, the first one being the default.
+var embedThemes = []string{"light", "dark"}
+
+// embedData holds one file of a snippet as shown in an embed page, in a range of its
+// lines, numbered as in the whole file. LinePrefix is the prefix of the anchors of the
+// file's lines in the snippet page, as in #F2-L12.
+type embedData struct {
+	Theme      string
+	Filename   string
+	Language   string
+	Lines      []embedLine
+	LinePrefix string
+	ViewURL    string
+}
+
+// embedLine is a highlighted line of an embedded file.
+type embedLine struct {
+	Number int
+	HTML   template.HTML
+}
+
+// embedScript is the script injecting an embedded snippet in the page which loads it,
+// right after its script tag. The iframe is resized to fit the snippet once the embed
+// page reports its height. It is formatted with the JSON encoding of the URL of the
+// embed page, the title of the iframe and the origin of the site.
+const embedScript = `(() => {
+  const script = document.currentScript;
+  const iframe = document.createElement("iframe");
+  iframe.src = %s;
+  iframe.title = %s;
+  iframe.loading = "lazy";
+  iframe.style.width = "100%%";
+  iframe.style.height = "320px";
+  iframe.style.border = "0";
+  script.parentNode.insertBefore(iframe, script.nextSibling);
+
+  window.addEventListener("message", (event) => {
+    if (event.origin === %s && event.source === iframe.contentWindow && event.data && event.data.ssnipp === "embed") {
+      iframe.style.height = event.data.height + "px";
+    }
+  });
+})();
+`
+
+// Snippet embed handler, showing one file of the snippet in a minimal page which other
+// sites can frame, or sending the script injecting that page when the slug ends in .js.
+// The file, theme and range of lines are set with the file, theme and lines query
+// parameters, as in /embed/aBcD3fGh1jKl.js?file=2&theme=dark&lines=12-30
+func (app *application) snippetEmbed(w http.ResponseWriter, r *http.Request) {
+	slug, isScript := strings.CutSuffix(r.PathValue("slug"), ".js")
+
+	// Check the options before looking the snippet up
+	theme := r.URL.Query().Get("theme")
+	if theme == "" {
+		theme = embedThemes[0]
+	}
+
+	number, err := readPositiveInt(r, "file", 1)
+	if err != nil || !validator.PermittedValue(theme, embedThemes) {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	lines, err := readLineRange(r, "lines")
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// Retrieve the snippet and the file to embed
+	snippet, err := app.snippetFromSlug(r, slug)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if !isEmbeddable(snippet) || number > len(snippet.AllFiles()) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// The script only needs to point the iframe at the embed page, with the same options
+	if isScript {
+		origin := requestOrigin(r)
+		src := fmt.Sprintf("%s/embed/%s", origin, url.PathEscape(snippet.Slug))
+		if r.URL.RawQuery != "" {
+			src += "?" + r.URL.Query().Encode()
+		}
+
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		fmt.Fprintf(w, embedScript, jsonString(src), jsonString(snippetTitle(snippet)), jsonString(origin))
+		return
+	}
+
+	file := snippet.AllFiles()[number-1]
+
+	embed := embedData{
+		Theme:    theme,
+		Filename: file.Filename,
+		Language: file.Language,
+		ViewURL:  fmt.Sprintf("/view/%s", snippet.Slug),
+	}
+	if number > 1 {
+		embed.LinePrefix = fmt.Sprintf("F%d-", number)
+	}
+
+	// Keep the lines within the range, numbered as in the whole file
+	highlighted := splitLines(app.highlightFiles(snippet, 0)[number-1])
+	first, last := 1, len(highlighted)
+	if lines != (lineRange{}) {
+		first, last = lines.start, min(lines.end, last)
+	}
+	for n := first; n <= last; n++ {
+		embed.Lines = append(embed.Lines, embedLine{Number: n, HTML: highlighted[n-1]})
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Embed = embed
+
+	app.render(w, r, http.StatusOK, "embed.html", data)
+}
+
+// requestOrigin returns the origin the request was sent to, such as https://ssnipp.com,
+// to build absolute URLs. Requests forwarded by a TLS-terminating proxy are recognised
+// by their X-Forwarded-Proto header.
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+// jsonString returns the JSON encoding of the string, which is also a JavaScript string
+// literal. Characters such as < are escaped, so it can't end the script it is in.
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
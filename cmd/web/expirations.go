@@ -0,0 +1,56 @@
+package main
+
+import "time"
+
+// Expiration represents a lifetime option for new snippets, with a key and a value.
+type Expiration struct {
+	Key   string
+	Value string
+}
+
+// getExpirations returns a slice of Expiration structs containing the lifetime options.
+// Each Expiration struct contains a key (used internally) and a value (display name).
+func getExpirations() []Expiration {
+	pairs := []Expiration{
+		{Key: "never", Value: "Never"},
+		{Key: "1h", Value: "1 hour"},
+		{Key: "1d", Value: "1 day"},
+		{Key: "1w", Value: "1 week"},
+		{Key: "1m", Value: "1 month"},
+		{Key: "burn", Value: "Burn after reading"},
+	}
+	return pairs
+}
+
+// getExpirationKeys returns the keys of the lifetime options.
+func getExpirationKeys() []string {
+	// Retrieve the list of expirations.
+	expirations := getExpirations()
+
+	// Initialize a slice to hold the keys.
+	keys := make([]string, len(expirations))
+
+	// Populate the slice with the keys of the expirations.
+	for i, e := range expirations {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// expiresAt returns the time at which a snippet created at the given time expires,
+// for a given expiration key. It returns the zero time for snippets which don't
+// expire after a delay, such as "never" and "burn".
+func expiresAt(key string, created time.Time) time.Time {
+	switch key {
+	case "1h":
+		return created.Add(time.Hour)
+	case "1d":
+		return created.AddDate(0, 0, 1)
+	case "1w":
+		return created.AddDate(0, 0, 7)
+	case "1m":
+		return created.AddDate(0, 1, 0)
+	default:
+		return time.Time{}
+	}
+}
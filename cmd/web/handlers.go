@@ -3,16 +3,267 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
+	"ssnipp.com/internal/languages"
 	"ssnipp.com/internal/models"
 	"ssnipp.com/internal/validator"
 )
 
+// snippetCreateForm holds the fields of a snippet submitted through the HTML forms or
+// the JSON API.
 type snippetCreateForm struct {
-	Content             string `form:"content"`
-	Language            string `form:"language"`
+	Content             string            `form:"content" json:"content"`
+	Language            string            `form:"language" json:"language"`
+	Expires             string            `form:"expires" json:"expires"`
+	Visibility          string            `form:"visibility" json:"visibility"`
+	Password            string            `form:"password" json:"password"`
+	Encrypted           bool              `form:"encrypted" json:"encrypted"`
+	Tags                []string          `form:"tags" json:"tags"`
+	Title               string            `form:"title" json:"title"`
+	Description         string            `form:"description" json:"description"`
+	Filename            string            `form:"filename" json:"filename"`
+	Files               []snippetFileForm `form:"files" json:"files"`
+	ForkedFrom          string            `form:"forked_from" json:"-"`
+	validator.Validator `form:"-" json:"-"`
+}
+
+// snippetFileForm holds the fields of an additional file of a snippet. The fields of the
+// main file are those of snippetCreateForm.
+type snippetFileForm struct {
+	Filename string `form:"filename" json:"filename"`
+	Language string `form:"language" json:"language"`
+	Content  string `form:"content" json:"content"`
+}
+
+// maxTags is the maximum number of tags of a snippet.
+const maxTags = 10
+
+// Maximum lengths of the title and description of a snippet, in characters.
+const (
+	maxTitleChars       = 100
+	maxDescriptionChars = 2000
+)
+
+// maxFiles is the maximum number of files of a snippet, including its main file.
+const maxFiles = 10
+
+// maxFilenameChars is the maximum length of the filenames of a snippet, in characters.
+const maxFilenameChars = 100
+
+// ciphertextRX matches the content of encrypted snippets, as sent by the browser: the
+// base64url encoding of a 12-byte IV followed by the AES-GCM ciphertext and its 16-byte
+// authentication tag.
+var ciphertextRX = regexp.MustCompile(`^[A-Za-z0-9_-]{38,}$`)
+
+// validate checks the files, visibility, tags, title and description of a snippet form,
+// recording any errors in the embedded Validator. It is shared by the create and edit
+// handlers. The content of encrypted snippets can't be checked beyond its format, as the
+// server never sees the plaintext. Their filenames, title and description aren't
+// encrypted.
+func (form *snippetCreateForm) validate() {
+	form.Tags = normalizeTags(form.Tags)
+	form.Title = strings.TrimSpace(form.Title)
+	form.Description = strings.TrimSpace(form.Description)
+	form.Filename = strings.TrimSpace(form.Filename)
+	form.Language = resolveLanguage(form.Language)
+
+	// Check the main file, then the additional files, whose errors are keyed by their
+	// position in the list
+	form.checkFile(form.Filename, form.Language, form.Content, "")
+	form.CheckField(validator.MaxItems(form.Files, maxFiles-1), "files", fmt.Sprintf("A snippet cannot have more than %d files", maxFiles))
+
+	filenames := map[string]bool{form.Filename: true}
+
+	for i := range form.Files {
+		file := &form.Files[i]
+		file.Filename = strings.TrimSpace(file.Filename)
+		file.Language = resolveLanguage(file.Language)
+
+		prefix := fmt.Sprintf("files.%d.", i)
+		form.checkFile(file.Filename, file.Language, file.Content, prefix)
+
+		// Files without a filename are identified by their position instead
+		if file.Filename != "" {
+			form.CheckField(!filenames[file.Filename], prefix+"filename", "Another file has the same name")
+			filenames[file.Filename] = true
+		}
+	}
+
+	form.CheckField(validator.PermittedValue(form.Visibility, getVisibilityKeys()), "visibility", "Choose a valid visibility")
+	form.CheckField(validator.MaxItems(form.Tags, maxTags), "tags", fmt.Sprintf("This field cannot contain more than %d tags", maxTags))
+	form.CheckField(validator.AllMatch(form.Tags, validator.TagRX), "tags", "Tags can only contain lowercase letters, digits, hyphens and underscores, up to 30 characters")
+	form.CheckField(validator.MaxChars(form.Title, maxTitleChars), "title", fmt.Sprintf("This field cannot be more than %d characters long", maxTitleChars))
+	form.CheckField(validator.MaxChars(form.Description, maxDescriptionChars), "description", fmt.Sprintf("This field cannot be more than %d characters long", maxDescriptionChars))
+}
+
+// checkFile checks the filename, language and content of one of the files of a snippet
+// form, recording any errors with keys starting with the given prefix.
+func (form *snippetCreateForm) checkFile(filename, language, content, prefix string) {
+	form.CheckField(validator.NotBlank(content), prefix+"content", "This field cannot be blank")
+	if form.Encrypted {
+		form.CheckField(validator.Matches(content, ciphertextRX), prefix+"content", "The encrypted content is invalid")
+	}
+	form.CheckField(isLanguage(language), prefix+"language", "Choose a valid language")
+
+	if filename != "" {
+		form.CheckField(validator.MaxChars(filename, maxFilenameChars), prefix+"filename", fmt.Sprintf("This field cannot be more than %d characters long", maxFilenameChars))
+		form.CheckField(validator.Matches(filename, validator.FilenameRX), prefix+"filename", "Filenames can only contain letters, digits, dots, hyphens, underscores and plus signs")
+	}
+}
+
+// clearEncryptedContent empties the content of the files of an encrypted snippet form,
+// which is ciphertext that can't be shown back when the form is re-displayed.
+func (form *snippetCreateForm) clearEncryptedContent() {
+	if !form.Encrypted {
+		return
+	}
+
+	form.Content = ""
+	for i := range form.Files {
+		form.Files[i].Content = ""
+	}
+}
+
+// modelFiles returns the additional files of a snippet form, as stored in the snippet.
+func (form *snippetCreateForm) modelFiles() []models.File {
+	var files []models.File
+
+	for _, file := range form.Files {
+		files = append(files, models.File{Filename: file.Filename, Language: file.Language, Content: file.Content})
+	}
+
+	return files
+}
+
+// fileForms returns the fields of the additional files of a snippet, to fill in a form.
+func fileForms(files []models.File) []snippetFileForm {
+	var forms []snippetFileForm
+
+	for _, file := range files {
+		forms = append(forms, snippetFileForm{Filename: file.Filename, Language: file.Language, Content: file.Content})
+	}
+
+	return forms
+}
+
+// normalizeTags splits the tags submitted in the form, where they are entered in a
+// single field separated by commas or spaces, and removes duplicates. Tags are
+// lowercased, so that "Go" and "go" are the same tag.
+func normalizeTags(values []string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+
+	for _, value := range values {
+		for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			tag = strings.ToLower(tag)
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return tags
+}
+
+// validateCreate checks a snippet form submitted to create a new snippet, including the
+// fields which can only be set on creation. The password is optional, and bcrypt only
+// supports passwords of up to 72 bytes. Files whose language is set to be detected get
+// the detected language first.
+func (form *snippetCreateForm) validateCreate() {
+	form.detectLanguages()
+	form.validate()
+	form.CheckField(validator.PermittedValue(form.Expires, getExpirationKeys()), "expires", "Choose a valid expiration")
+	form.CheckField(len(form.Password) <= 72, "password", "This field is too long")
+}
+
+// detectLanguages replaces the "auto" language of the files of the form with the language
+// detected from their filename and content. The content of encrypted snippets is
+// ciphertext, so only their filenames can tell their language.
+func (form *snippetCreateForm) detectLanguages() {
+	detect := func(filename, content string) string {
+		if form.Encrypted {
+			content = ""
+		}
+		return detectLanguage(filename, content)
+	}
+
+	if form.Language == autoLanguage {
+		form.Language = detect(form.Filename, form.Content)
+	}
+
+	for i := range form.Files {
+		if form.Files[i].Language == autoLanguage {
+			form.Files[i].Language = detect(form.Files[i].Filename, form.Files[i].Content)
+		}
+	}
+}
+
+// newSnippet returns the snippet described by a valid creation form, owned by the given
+// user and created at the given time.
+func (form *snippetCreateForm) newSnippet(userID int, created time.Time) (models.Snippet, error) {
+	snippet := models.Snippet{
+		Content:       form.Content,
+		Language:      form.Language,
+		UserID:        userID,
+		Expires:       expiresAt(form.Expires, created),
+		BurnAfterRead: form.Expires == "burn",
+		Visibility:    form.Visibility,
+		Encrypted:     form.Encrypted,
+		Tags:          form.Tags,
+		Title:         form.Title,
+		Description:   form.Description,
+		Filename:      form.Filename,
+		Files:         form.modelFiles(),
+	}
+
+	// Protect the snippet with the password, if one was given
+	if form.Password != "" {
+		err := snippet.SetPassword(form.Password)
+		if err != nil {
+			return models.Snippet{}, err
+		}
+	}
+
+	return snippet, nil
+}
+
+type snippetUnlockForm struct {
+	Password            string `form:"password"`
+	validator.Validator `form:"-"`
+}
+
+// searchForm holds the query string parameters of a search.
+type searchForm struct {
+	Query    string
+	Language string
+}
+
+// collectionCreateForm holds the fields of a new collection.
+type collectionCreateForm struct {
+	Name                string `form:"name"`
+	validator.Validator `form:"-"`
+}
+
+// collectionSnippetForm identifies a snippet and the collection to add it to.
+type collectionSnippetForm struct {
+	Collection string `form:"collection"`
+	Snippet    string `form:"snippet"`
+}
+
+// tokenCreateForm holds the fields of a new personal API token.
+type tokenCreateForm struct {
+	Name                string `form:"name"`
+	Scope               string `form:"scope"`
 	validator.Validator `form:"-"`
 }
 
@@ -33,12 +284,16 @@ type userLoginForm struct {
 func (app *application) home(w http.ResponseWriter, r *http.Request) {
 	data := app.newTemplateData(r)
 
-	// Load available languages
-	data.Languages = getLanguages()
+	// Load available languages, expiration and visibility options
+	data.Languages = getCreateLanguages()
+	data.Expirations = getExpirations()
+	data.Visibilities = getVisibilities()
 
 	// Initialize form with default values
 	data.Form = snippetCreateForm{
-		Language: "plaintext",
+		Language:   autoLanguage,
+		Expires:    "never",
+		Visibility: models.VisibilityUnlisted,
 	}
 
 	app.render(w, r, http.StatusOK, "home.html", data)
@@ -46,15 +301,39 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 
 // View snippet handler
 func (app *application) snippetView(w http.ResponseWriter, r *http.Request) {
-	// Get the ID of the snippet from the URL parameter
-	id, err := strconv.Atoi(r.PathValue("id"))
-	if err != nil || id < 1 {
-		http.NotFound(w, r)
+	// Snippets used to be identified by their sequential ID. Redirect these legacy
+	// URLs to the current ones for the owner of the snippet, who may have old links
+	// around, without revealing the slug of the snippet to anyone else.
+	if id, err := strconv.Atoi(r.PathValue("slug")); err == nil {
+		app.legacySnippetRedirect(w, r, id)
 		return
 	}
 
-	// Retrieve the snippet from the database
-	snippet, err := app.snippets.Get(id)
+	// Retrieve the snippet identified in the URL
+	snippet, ok := app.snippetFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Ask for the password of protected snippets until it has been entered
+	if !app.isUnlocked(r, snippet) {
+		data := app.newTemplateData(r)
+		data.Snippet = models.Snippet{Slug: snippet.Slug}
+		data.Form = snippetUnlockForm{}
+
+		app.render(w, r, http.StatusOK, "unlock.html", data)
+		return
+	}
+
+	// Keep encrypted snippets out of search engines, as their content is meaningless
+	// without the key
+	if snippet.Encrypted {
+		w.Header().Set("X-Robots-Tag", "noindex")
+	}
+
+	// Burn-after-reading snippets are deleted as soon as someone other than their
+	// owner reads them. If the snippet can't be burned, another reader got it first.
+	err := app.burnOnRead(r, snippet)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
 			http.NotFound(w, r)
@@ -67,13 +346,44 @@ func (app *application) snippetView(w http.ResponseWriter, r *http.Request) {
 	// Prepare template data
 	data := app.newTemplateData(r)
 	data.Snippet = snippet
+	data.Highlighted = app.highlightFiles(snippet, 0)
+	data.Rendered = app.renderFiles(snippet, 0)
+
+	// Load the snippet this one was forked from, to link to it if the user could find it
+	// anyway. Otherwise, only its number is shown.
+	if snippet.ForkedFrom != 0 {
+		forkedFrom, err := app.snippets.Get(snippet.ForkedFrom)
+		if err != nil && !errors.Is(err, models.ErrNoRecord) {
+			app.serverError(w, r, err)
+			return
+		}
+
+		if err == nil && app.isListed(r, forkedFrom) {
+			data.ForkedFrom = forkedFrom
+		}
+	}
+
+	// Load the collections of the user, to offer adding the snippet to them
+	if app.isAuthenticated(r) && app.isListed(r, snippet) {
+		data.Collections, err = app.collections.ListByUser(app.authenticatedUserID(r))
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
 
 	app.render(w, r, http.StatusOK, "view.html", data)
 }
 
-// Create snippet handler (POST)
-func (app *application) snippetCreatePost(w http.ResponseWriter, r *http.Request) {
-	var form snippetCreateForm
+// Unlock snippet handler (POST), checking the password of a protected snippet
+func (app *application) snippetUnlockPost(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the snippet identified in the URL
+	snippet, ok := app.snippetFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var form snippetUnlockForm
 
 	// Decode the form data
 	err := app.decodePostForm(r, &form)
@@ -83,32 +393,938 @@ func (app *application) snippetCreatePost(w http.ResponseWriter, r *http.Request
 	}
 
 	// Validate the form contents
-	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
-	form.CheckField(validator.PermittedValue(form.Language, getLanguageKeys()), "language", "Choose a valid language")
+	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be blank")
 
-	// If there are any validation errors, re-display the form
+	if form.Valid() && snippet.HasPassword() {
+		ok, err := snippet.MatchesPassword(form.Password)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		form.CheckField(ok, "password", "The password is incorrect")
+	}
+
+	// If the password is wrong, re-display the unlock form
 	if !form.Valid() {
 		data := app.newTemplateData(r)
+		data.Snippet = models.Snippet{Slug: snippet.Slug}
+		data.Form = form
 
-		data.Languages = getLanguages()
+		app.render(w, r, http.StatusUnprocessableEntity, "unlock.html", data)
+		return
+	}
 
-		data.Form = form
-		app.render(w, r, http.StatusUnprocessableEntity, "home.html", data)
+	// Remember the unlock in the session, so reloads don't ask for the password again
+	app.sessionManager.Put(r.Context(), unlockSessionKey(snippet.Slug), true)
+
+	// Redirect to the snippet view page
+	http.Redirect(w, r, fmt.Sprintf("/view/%s", snippet.Slug), http.StatusSeeOther)
+}
+
+// legacySnippetRedirect redirects the owner of a snippet from its legacy URL, based
+// on its sequential ID, to its current URL. Anyone else gets a 404 Not Found response.
+func (app *application) legacySnippetRedirect(w http.ResponseWriter, r *http.Request, id int) {
+	if id < 1 || !app.isAuthenticated(r) {
+		http.NotFound(w, r)
+		return
+	}
+
+	snippet, err := app.snippets.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if !app.isOwner(r, snippet) {
+		http.NotFound(w, r)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/view/%s", snippet.Slug), http.StatusMovedPermanently)
+}
+
+// Raw snippet handler, sending the content of one of the files of the snippet as plain
+// text, its main file by default. The lines query parameter, such as ?lines=12-30, limits
+// the content to a range of lines
+func (app *application) snippetRaw(w http.ResponseWriter, r *http.Request) {
+	// Reject invalid line ranges before looking the snippet up, so that burn-after-read
+	// snippets aren't burned by a malformed request
+	lines, err := readLineRange(r, "lines")
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// Retrieve the file identified in the URL
+	_, file, _, ok := app.snippetContentFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, selectLines(file.Content, lines))
+}
+
+// Snippet download handler, sending the content of one of the files of the snippet as a
+// file, its main file by default. Files without a filename are named after the slug of
+// the snippet and their position, with the extension of their language
+func (app *application) snippetDownload(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the snippet and the file identified in the URL
+	snippet, file, number, ok := app.snippetContentFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	filename := file.Filename
+	if filename == "" {
+		filename = snippet.Slug
+		if number > 1 {
+			filename += fmt.Sprintf("-%d", number)
+		}
+		filename += languages.Default.Extension(file.Language)
+	}
+
+	w.Header().Set("Content-Type", languages.Default.MIMEType(file.Language)+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	io.WriteString(w, file.Content)
+}
+
+// Snippet history handler, listing the revisions of a snippet
+func (app *application) snippetHistory(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the snippet identified in the URL
+	snippet, ok := app.snippetHistoryFromPath(w, r)
+	if !ok {
 		return
 	}
 
-	// Insert the snippet into the database
-	id, err := app.snippets.Insert(form.Content, form.Language)
+	// Retrieve the revisions of the snippet
+	revisions, err := app.snippets.Revisions(snippet.ID)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	// Add a flash message to the session
-	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully created!")
+	// Prepare template data
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Revisions = revisions
 
-	// Redirect to the snippet view page
-	http.Redirect(w, r, fmt.Sprintf("/view/%d", id), http.StatusSeeOther)
+	app.render(w, r, http.StatusOK, "history.html", data)
+}
+
+// View snippet revision handler
+func (app *application) snippetRevisionView(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the snippet identified in the URL
+	snippet, ok := app.snippetHistoryFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Get the revision number from the URL parameter
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil || n < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Retrieve the revision from the database
+	revision, err := app.snippets.GetRevision(snippet.ID, n)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// Show the snippet as it was at that revision
+	snippet.Title = revision.Title
+	snippet.Filename = revision.Filename
+	snippet.Content = revision.Content
+	snippet.Language = revision.Language
+	snippet.Files = revision.Files
+
+	// Prepare template data
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Highlighted = app.highlightFiles(snippet, revision.Number)
+	data.Rendered = app.renderFiles(snippet, revision.Number)
+	data.Revision = revision
+
+	app.render(w, r, http.StatusOK, "view.html", data)
+}
+
+// Snippet list handler, showing the snippets of the authenticated user
+func (app *application) snippetList(w http.ResponseWriter, r *http.Request) {
+	// Get the requested page number from the query string
+	page, err := readPositiveInt(r, "page", 1)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	p := newPagination(page, snippetsPerPage, 0)
+
+	// Retrieve the page of snippets owned by the authenticated user
+	snippets, total, err := app.snippets.ListByUser(app.authenticatedUserID(r), p.PageSize, p.Offset())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	p.TotalRecords = total
+
+	// Prepare template data
+	data := app.newTemplateData(r)
+	data.Snippets = snippets
+	data.Pagination = p
+
+	app.render(w, r, http.StatusOK, "snippets.html", data)
+}
+
+// Explore handler, listing the public snippets of all users
+func (app *application) snippetExplore(w http.ResponseWriter, r *http.Request) {
+	// Get the requested page number from the query string
+	page, err := readPositiveInt(r, "page", 1)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	p := newPagination(page, snippetsPerPage, 0)
+
+	// Retrieve the page of public snippets
+	snippets, total, err := app.snippets.ListPublic(p.PageSize, p.Offset())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	p.TotalRecords = total
+
+	// Prepare template data
+	data := app.newTemplateData(r)
+	data.Snippets = snippets
+	data.Pagination = p
+
+	app.render(w, r, http.StatusOK, "explore.html", data)
+}
+
+// Tag page handler, listing the snippets with a tag which are visible to the user
+func (app *application) snippetTag(w http.ResponseWriter, r *http.Request) {
+	tag := r.PathValue("tag")
+	if !validator.Matches(tag, validator.TagRX) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Get the requested page number from the query string
+	page, err := readPositiveInt(r, "page", 1)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	p := newPagination(page, snippetsPerPage, 0)
+
+	// Retrieve the page of snippets with the tag
+	snippets, total, err := app.snippets.ListByTag(tag, app.authenticatedUserID(r), p.PageSize, p.Offset())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	p.TotalRecords = total
+
+	// Prepare template data
+	data := app.newTemplateData(r)
+	data.Tag = tag
+	data.Snippets = snippets
+	data.Pagination = p
+
+	app.render(w, r, http.StatusOK, "tag.html", data)
+}
+
+// Snippet search handler, searching the content of the snippets visible to the user
+func (app *application) snippetSearch(w http.ResponseWriter, r *http.Request) {
+	form := searchForm{
+		Query:    strings.TrimSpace(r.URL.Query().Get("q")),
+		Language: resolveLanguage(r.URL.Query().Get("lang")),
+	}
+
+	// Reject invalid languages and overly long queries
+	if !validator.MaxChars(form.Query, 200) || (form.Language != "" && !isLanguage(form.Language)) {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// Get the requested page number from the query string
+	page, err := readPositiveInt(r, "page", 1)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Form = form
+	data.Languages = getLanguages()
+
+	// Only show the search form until a query is submitted
+	if form.Query == "" {
+		app.render(w, r, http.StatusOK, "search.html", data)
+		return
+	}
+
+	p := newPagination(page, snippetsPerPage, 0)
+	p.Query = url.Values{"q": {form.Query}}
+	if form.Language != "" {
+		p.Query.Set("lang", form.Language)
+	}
+
+	// Retrieve the page of matching snippets
+	snippets, total, err := app.snippets.Search(form.Query, form.Language, app.authenticatedUserID(r), p.PageSize, p.Offset())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	p.TotalRecords = total
+
+	// Find the matching lines of each snippet
+	results := make([]searchResult, len(snippets))
+	for i, s := range snippets {
+		results[i] = searchResult{Snippet: s, Lines: snippetMatchingLines(s, form.Query)}
+	}
+
+	data.SearchResults = results
+	data.Pagination = p
+
+	app.render(w, r, http.StatusOK, "search.html", data)
+}
+
+// Snippet diff handler, showing the changes between two revisions of a snippet
+func (app *application) snippetDiff(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the snippet identified in the URL
+	snippet, ok := app.snippetHistoryFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Retrieve the revisions of the snippet, most recent first
+	revisions, err := app.snippets.Revisions(snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if len(revisions) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Read the revision numbers to compare from the query string. By default, the
+	// latest revision is compared with the one before it.
+	to, err := readPositiveInt(r, "to", revisions[0].Number)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	from, err := readPositiveInt(r, "from", max(to-1, 1))
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// Retrieve the requested revisions, along with their files
+	var compared [2]models.Revision
+	for i, n := range []int{from, to} {
+		compared[i], err = app.snippets.GetRevision(snippet.ID, n)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				http.NotFound(w, r)
+			} else {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+	}
+
+	// Prepare template data
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Diff = revisionDiff{
+		From:  compared[0],
+		To:    compared[1],
+		Files: diffFiles(compared[0], compared[1]),
+	}
+
+	app.render(w, r, http.StatusOK, "diff.html", data)
+}
+
+// Fork snippet page handler, showing the home page form filled in with a copy of the snippet
+func (app *application) snippetFork(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the snippet identified in the URL
+	snippet, ok := app.snippetFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Ask for the password of protected snippets first
+	if !app.isUnlocked(r, snippet) {
+		http.Redirect(w, r, fmt.Sprintf("/view/%s", snippet.Slug), http.StatusSeeOther)
+		return
+	}
+
+	if !app.isForkable(r, snippet) {
+		http.NotFound(w, r)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.ForkedFrom = snippet
+
+	// Load available languages, expiration and visibility options
+	data.Languages = getCreateLanguages()
+	data.Expirations = getExpirations()
+	data.Visibilities = getVisibilities()
+
+	// Initialize form with a copy of the snippet, and the default options
+	data.Form = snippetCreateForm{
+		Content:     snippet.Content,
+		Language:    snippet.Language,
+		Expires:     "never",
+		Visibility:  models.VisibilityUnlisted,
+		Tags:        snippet.Tags,
+		Title:       snippet.Title,
+		Description: snippet.Description,
+		Filename:    snippet.Filename,
+		Files:       fileForms(snippet.Files),
+		ForkedFrom:  snippet.Slug,
+	}
+
+	app.render(w, r, http.StatusOK, "home.html", data)
+}
+
+// Create snippet handler (POST)
+func (app *application) snippetCreatePost(w http.ResponseWriter, r *http.Request) {
+	var form snippetCreateForm
+
+	// Decode the form data
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// Retrieve the snippet being forked, if any. If it can't be forked anymore, such as
+	// if it was deleted in the meantime, the snippet is created without the reference.
+	var forkedFrom models.Snippet
+	if form.ForkedFrom != "" {
+		forkedFrom, err = app.snippetFromSlug(r, form.ForkedFrom)
+		if err != nil && !errors.Is(err, models.ErrNoRecord) {
+			app.serverError(w, r, err)
+			return
+		}
+
+		if err != nil || !app.isUnlocked(r, forkedFrom) || !app.isForkable(r, forkedFrom) {
+			forkedFrom = models.Snippet{}
+			form.ForkedFrom = ""
+		}
+	}
+
+	// Validate the form contents
+	form.validateCreate()
+
+	// If there are any validation errors, re-display the form. The content of encrypted
+	// snippets is ciphertext at this point, so it can't be shown back.
+	if !form.Valid() {
+		form.clearEncryptedContent()
+
+		data := app.newTemplateData(r)
+		data.ForkedFrom = forkedFrom
+
+		data.Languages = getCreateLanguages()
+		data.Expirations = getExpirations()
+		data.Visibilities = getVisibilities()
+
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "home.html", data)
+		return
+	}
+
+	snippet, err := form.newSnippet(app.authenticatedUserID(r), time.Now())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	snippet.ForkedFrom = forkedFrom.ID
+
+	// Insert the snippet into the database, owned by the authenticated user
+	err = app.snippets.Insert(&snippet)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Add a flash message to the session
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully created!")
+
+	// Redirect to the snippet view page
+	http.Redirect(w, r, fmt.Sprintf("/view/%s", snippet.Slug), http.StatusSeeOther)
+}
+
+// Edit snippet page handler
+func (app *application) snippetEdit(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the snippet identified in the URL
+	snippet, ok := app.snippetFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Only the owner of the snippet can edit it
+	if !app.isOwner(r, snippet) {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+
+	// Load available languages and visibility options
+	data.Languages = getLanguages()
+	data.Visibilities = getVisibilities()
+
+	// Initialize form with the current snippet values
+	data.Form = snippetCreateForm{
+		Content:     snippet.Content,
+		Language:    snippet.Language,
+		Visibility:  snippet.Visibility,
+		Encrypted:   snippet.Encrypted,
+		Tags:        snippet.Tags,
+		Title:       snippet.Title,
+		Description: snippet.Description,
+		Filename:    snippet.Filename,
+		Files:       fileForms(snippet.Files),
+	}
+
+	app.render(w, r, http.StatusOK, "edit.html", data)
+}
+
+// Edit snippet handler (POST)
+func (app *application) snippetEditPost(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the snippet identified in the URL
+	snippet, ok := app.snippetFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Only the owner of the snippet can edit it
+	if !app.isOwner(r, snippet) {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	var form snippetCreateForm
+
+	// Decode the form data
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// Snippets can't be encrypted or decrypted once created
+	form.Encrypted = snippet.Encrypted
+
+	// Validate the form contents
+	form.validate()
+
+	// If there are any validation errors, re-display the form
+	if !form.Valid() {
+		form.clearEncryptedContent()
+
+		data := app.newTemplateData(r)
+		data.Snippet = snippet
+
+		data.Languages = getLanguages()
+		data.Visibilities = getVisibilities()
+
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "edit.html", data)
+		return
+	}
+
+	snippet.Content = form.Content
+	snippet.Language = form.Language
+	snippet.Visibility = form.Visibility
+	snippet.Tags = form.Tags
+	snippet.Title = form.Title
+	snippet.Description = form.Description
+	snippet.Filename = form.Filename
+	snippet.Files = form.modelFiles()
+
+	// Save the changes, which also appends a new revision
+	err = app.snippets.Update(&snippet)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Add a flash message to the session
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully updated!")
+
+	// Redirect to the snippet view page
+	http.Redirect(w, r, fmt.Sprintf("/view/%s", snippet.Slug), http.StatusSeeOther)
+}
+
+// Delete snippet handler (POST)
+func (app *application) snippetDeletePost(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the snippet identified in the URL
+	snippet, ok := app.snippetFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Only the owner of the snippet can delete it
+	if !app.isOwner(r, snippet) {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	// Mark the snippet as deleted, it will be purged after the retention period
+	err := app.snippets.Delete(snippet.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// Add a flash message to the session
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully deleted!")
+
+	// Redirect to the list of snippets of the user
+	http.Redirect(w, r, "/snippets", http.StatusSeeOther)
+}
+
+// Collections page handler, listing the collections of the user
+func (app *application) collectionList(w http.ResponseWriter, r *http.Request) {
+	app.renderCollections(w, r, http.StatusOK, collectionCreateForm{})
+}
+
+// renderCollections renders the collections page of the authenticated user with the given
+// form and status code.
+func (app *application) renderCollections(w http.ResponseWriter, r *http.Request, status int, form collectionCreateForm) {
+	collections, err := app.collections.ListByUser(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Collections = collections
+	data.Form = form
+
+	app.render(w, r, status, "collections.html", data)
+}
+
+// Create collection handler (POST)
+func (app *application) collectionCreatePost(w http.ResponseWriter, r *http.Request) {
+	var form collectionCreateForm
+
+	// Decode the form data
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// Validate the form contents
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Name, 100), "name", "This field cannot be more than 100 characters long")
+
+	// If there are any validation errors, re-display the page
+	if !form.Valid() {
+		app.renderCollections(w, r, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	collection, err := app.collections.Insert(app.authenticatedUserID(r), form.Name)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Add a flash message to the session
+	app.sessionManager.Put(r.Context(), "flash", "Collection successfully created!")
+
+	// Redirect to the new collection
+	http.Redirect(w, r, fmt.Sprintf("/collections/%s", collection.Slug), http.StatusSeeOther)
+}
+
+// collectionFromSlug retrieves the collection with the given slug. If the collection can't
+// be found, it sends a 404 Not Found response, and a 500 Internal Server Error response for
+// any other error. The boolean result reports whether the collection was found.
+func (app *application) collectionFromSlug(w http.ResponseWriter, r *http.Request, slug string) (models.Collection, bool) {
+	if !slugRX.MatchString(slug) {
+		http.NotFound(w, r)
+		return models.Collection{}, false
+	}
+
+	collection, err := app.collections.GetBySlug(slug)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return models.Collection{}, false
+	}
+
+	return collection, true
+}
+
+// ownCollectionFromSlug retrieves the collection with the given slug like
+// collectionFromSlug, for handlers which modify it. It sends a 403 Forbidden response if
+// the collection doesn't belong to the authenticated user.
+func (app *application) ownCollectionFromSlug(w http.ResponseWriter, r *http.Request, slug string) (models.Collection, bool) {
+	collection, ok := app.collectionFromSlug(w, r, slug)
+	if !ok {
+		return models.Collection{}, false
+	}
+
+	if collection.UserID != app.authenticatedUserID(r) {
+		app.clientError(w, http.StatusForbidden)
+		return models.Collection{}, false
+	}
+
+	return collection, true
+}
+
+// Collection view handler, listing the snippets of the collection visible to the user
+func (app *application) collectionView(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the collection identified in the URL
+	collection, ok := app.collectionFromSlug(w, r, r.PathValue("slug"))
+	if !ok {
+		return
+	}
+
+	snippets, err := app.collections.Snippets(collection.ID, app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Prepare template data
+	data := app.newTemplateData(r)
+	data.Collection = collection
+	data.Snippets = snippets
+
+	app.render(w, r, http.StatusOK, "collection.html", data)
+}
+
+// Delete collection handler (POST)
+func (app *application) collectionDeletePost(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the collection identified in the URL, which must belong to the user
+	collection, ok := app.ownCollectionFromSlug(w, r, r.PathValue("slug"))
+	if !ok {
+		return
+	}
+
+	// Delete the collection, leaving its snippets untouched
+	err := app.collections.Delete(collection.ID, collection.UserID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// Add a flash message to the session
+	app.sessionManager.Put(r.Context(), "flash", "Collection successfully deleted!")
+
+	// Redirect to the list of collections
+	http.Redirect(w, r, "/collections", http.StatusSeeOther)
+}
+
+// Add snippet to collection handler (POST)
+func (app *application) collectionAddPost(w http.ResponseWriter, r *http.Request) {
+	var form collectionSnippetForm
+
+	// Decode the form data
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// Retrieve the chosen collection, which must belong to the user
+	collection, ok := app.ownCollectionFromSlug(w, r, form.Collection)
+	if !ok {
+		return
+	}
+
+	// Retrieve the snippet, which must be one that collections can list
+	snippet, err := app.snippetFromSlug(r, form.Snippet)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.clientError(w, http.StatusBadRequest)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if !app.isListed(r, snippet) {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.collections.AddSnippet(collection.ID, snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Add a flash message to the session
+	app.sessionManager.Put(r.Context(), "flash", fmt.Sprintf("Snippet added to %s!", collection.Name))
+
+	// Redirect back to the snippet view page
+	http.Redirect(w, r, fmt.Sprintf("/view/%s", snippet.Slug), http.StatusSeeOther)
+}
+
+// Remove snippet from collection handler (POST)
+func (app *application) collectionRemovePost(w http.ResponseWriter, r *http.Request) {
+	// Retrieve the collection identified in the URL, which must belong to the user
+	collection, ok := app.ownCollectionFromSlug(w, r, r.PathValue("slug"))
+	if !ok {
+		return
+	}
+
+	// Snippets which are no longer visible can still be removed, so the snippet is
+	// looked up without the usual access rules
+	snippetSlug := r.PathValue("snippet")
+	if !slugRX.MatchString(snippetSlug) {
+		http.NotFound(w, r)
+		return
+	}
+
+	snippet, err := app.snippets.GetBySlug(snippetSlug)
+	if err == nil {
+		err = app.collections.RemoveSnippet(collection.ID, snippet.ID)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// Add a flash message to the session
+	app.sessionManager.Put(r.Context(), "flash", "Snippet removed from the collection!")
+
+	// Redirect to the collection
+	http.Redirect(w, r, fmt.Sprintf("/collections/%s", collection.Slug), http.StatusSeeOther)
+}
+
+// renderTokens renders the personal API tokens page of the authenticated user with the
+// given form and status code. The plain-text value of a newly created token is popped
+// from the session, so that it is shown only once, as only its hash is stored.
+func (app *application) renderTokens(w http.ResponseWriter, r *http.Request, status int, form tokenCreateForm) {
+	tokens, err := app.tokens.ListByUser(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Tokens = tokens
+	data.NewToken = app.sessionManager.PopString(r.Context(), "newToken")
+	data.Scopes = getScopes()
+	data.Form = form
+
+	app.render(w, r, status, "tokens.html", data)
+}
+
+// API tokens page handler
+func (app *application) tokenList(w http.ResponseWriter, r *http.Request) {
+	app.renderTokens(w, r, http.StatusOK, tokenCreateForm{Scope: models.ScopeRead})
+}
+
+// Create API token handler (POST)
+func (app *application) tokenCreatePost(w http.ResponseWriter, r *http.Request) {
+	var form tokenCreateForm
+
+	// Decode the form data
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// Validate the form contents
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Name, 100), "name", "This field cannot be more than 100 characters long")
+	form.CheckField(validator.PermittedValue(form.Scope, getScopeKeys()), "scope", "This field must be a valid scope")
+
+	// If there are any validation errors, re-display the page
+	if !form.Valid() {
+		app.renderTokens(w, r, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	// Generate the token for the authenticated user
+	token, err := app.tokens.Insert(app.authenticatedUserID(r), form.Name, form.Scope)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Keep the token in the session until the list of tokens shows it
+	app.sessionManager.Put(r.Context(), "newToken", token.Plaintext)
+
+	// Redirect to the list of tokens
+	http.Redirect(w, r, "/account/tokens", http.StatusSeeOther)
+}
+
+// Revoke API token handler (POST)
+func (app *application) tokenRevokePost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Only tokens of the authenticated user can be revoked
+	err = app.tokens.Revoke(id, app.authenticatedUserID(r))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// Add a flash message to the session
+	app.sessionManager.Put(r.Context(), "flash", "Token successfully revoked!")
+
+	// Redirect to the list of tokens
+	http.Redirect(w, r, "/account/tokens", http.StatusSeeOther)
 }
 
 // User signup page handler
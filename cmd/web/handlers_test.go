@@ -3,9 +3,11 @@ package main
 import (
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 
 	"ssnipp.com/internal/assert"
+	"ssnipp.com/internal/models/mocks"
 )
 
 // TestPing tests the /ping endpoint to ensure it returns a 200 OK status and "OK" body.
@@ -27,7 +29,7 @@ func TestPing(t *testing.T) {
 	assert.Equal(t, body, "OK")
 }
 
-// TestSnippetView tests the /view/{id} endpoint with various IDs to check for proper handling.
+// TestSnippetView tests the /view/{slug} endpoint with various slugs to check for proper handling.
 func TestSnippetView(t *testing.T) {
 	// Create a new instance of our application struct which uses the mocked dependencies.
 	app := newTestApplication(t)
@@ -44,14 +46,96 @@ func TestSnippetView(t *testing.T) {
 		wantBody string // Expected response body (if any).
 	}{
 		{
-			name:     "Valid ID",
+			name:     "Valid slug",
+			urlPath:  "/view/aBcD3fGh1jKl",
+			wantCode: http.StatusOK,
+			wantBody: `<code id="snippet" class="hljs language-javascript" data-snippet-file data-line-prefix=""><span data-line="1"><span class="hljs-built_in">console</span>.log();</span></code>`,
+		},
+		{
+			name:     "Line numbers",
+			urlPath:  "/view/aBcD3fGh1jKl",
+			wantCode: http.StatusOK,
+			wantBody: `<span class="line-numbers" aria-hidden="true"><a id="L1" href="#L1" data-line-number>1</a></span>`,
+		},
+		{
+			name:     "Line numbers of additional files",
+			urlPath:  "/view/mUlT1f1l3Sn1",
+			wantCode: http.StatusOK,
+			wantBody: `<a id="F2-L1" href="#F2-L1" data-line-number>1</a>`,
+		},
+		{
+			name:     "Tags",
+			urlPath:  "/view/aBcD3fGh1jKl",
+			wantCode: http.StatusOK,
+			wantBody: "href='/tags/frontend'>#frontend</a>",
+		},
+		{
+			name:     "Title",
+			urlPath:  "/view/aBcD3fGh1jKl",
+			wantCode: http.StatusOK,
+			wantBody: "<title>Log to the console - ssnipp</title>",
+		},
+		{
+			name:     "Multiple files",
+			urlPath:  "/view/mUlT1f1l3Sn1",
+			wantCode: http.StatusOK,
+			wantBody: "href='/raw/mUlT1f1l3Sn1/2'>Raw</a>",
+		},
+		{
+			name:     "Unnamed file",
+			urlPath:  "/view/mUlT1f1l3Sn1",
+			wantCode: http.StatusOK,
+			wantBody: "File 3",
+		},
+		{
+			name:     "Rendered Markdown file",
+			urlPath:  "/view/mUlT1f1l3Sn1",
+			wantCode: http.StatusOK,
+			wantBody: "<div class=\"markdown p-4 text-gray-700 break-words\" data-rendered-file='2'><p>Run <strong>build.sh</strong> from the project root.</p>",
+		},
+		{
+			name:     "Markdown source toggle",
+			urlPath:  "/view/mUlT1f1l3Sn1",
+			wantCode: http.StatusOK,
+			wantBody: "data-toggle-source='2'>Source</button>",
+		},
+		{
+			name:     "Forked from",
+			urlPath:  "/view/mUlT1f1l3Sn1",
+			wantCode: http.StatusOK,
+			wantBody: "Forked from <a class=\"font-medium text-gray-950 hover:text-gray-400\" href='/view/aBcD3fGh1jKl'>#1</a>",
+		},
+		{
+			name:     "Forks",
+			urlPath:  "/view/aBcD3fGh1jKl",
+			wantCode: http.StatusOK,
+			wantBody: " · 1 fork</p>",
+		},
+		{
+			name:     "Markdown description",
+			urlPath:  "/view/aBcD3fGh1jKl",
+			wantCode: http.StatusOK,
+			wantBody: `<a href="https://developer.mozilla.org/en-US/docs/Web/API/console/log" rel="nofollow ugc noopener">the docs</a>`,
+		},
+		{
+			name:     "Non-existent slug",
+			urlPath:  "/view/xXxXxXxXxXxX",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Legacy ID",
 			urlPath:  "/view/1",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Burn after reading",
+			urlPath:  "/view/bUrN4fTeR5rD",
 			wantCode: http.StatusOK,
-			wantBody: "console.log();",
+			wantBody: "This snippet has now been deleted and can't be viewed again.",
 		},
 		{
-			name:     "Non-existent ID",
-			urlPath:  "/view/2",
+			name:     "Private",
+			urlPath:  "/view/pR1v4t3sN1pP",
 			wantCode: http.StatusNotFound,
 		},
 		{
@@ -65,7 +149,7 @@ func TestSnippetView(t *testing.T) {
 			wantCode: http.StatusNotFound,
 		},
 		{
-			name:     "String ID",
+			name:     "Invalid slug",
 			urlPath:  "/view/foo",
 			wantCode: http.StatusNotFound,
 		},
@@ -91,6 +175,1434 @@ func TestSnippetView(t *testing.T) {
 			}
 		})
 	}
+
+	// Log in as the mocked user who owns the mocked snippet.
+	ts.login(t, "alice@example.com")
+
+	t.Run("Legacy ID for the owner", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/view/1")
+
+		// Assert that the owner is redirected to the current URL of the snippet.
+		assert.Equal(t, code, http.StatusMovedPermanently)
+		assert.Equal(t, headers.Get("Location"), "/view/aBcD3fGh1jKl")
+	})
+
+	t.Run("Private for the owner", func(t *testing.T) {
+		code, _, body := ts.get(t, "/view/pR1v4t3sN1pP")
+
+		// Assert that the owner can view their private snippet.
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "DEBUG=true")
+	})
+}
+
+// TestSnippetUnlock tests that password-protected snippets are only shown once their
+// password has been entered, and that the unlock is remembered in the session.
+func TestSnippetUnlock(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	t.Run("Visitor", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		// Assert that the unlock form is shown instead of the content.
+		code, _, body := ts.get(t, "/view/pR0t3ct3dSn1")
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "This snippet is protected by a password.")
+		assert.Equal(t, strings.Contains(body, "DB_PASSWORD=secret"), false)
+
+		csrfToken := extractCSRFToken(t, body)
+
+		// Assert that the history can't be used to read the content.
+		code, headers, _ := ts.get(t, "/view/pR0t3ct3dSn1/history")
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/view/pR0t3ct3dSn1")
+
+		// Assert that a wrong password re-displays the form.
+		form := url.Values{}
+		form.Add("password", "letmein")
+		form.Add("csrf_token", csrfToken)
+
+		code, _, body = ts.postForm(t, "/view/pR0t3ct3dSn1/unlock", form)
+		assert.Equal(t, code, http.StatusUnprocessableEntity)
+		assert.StringContains(t, body, "The password is incorrect")
+
+		// Assert that the right password unlocks the snippet for the rest of the session.
+		form.Set("password", "opensesame")
+
+		code, headers, _ = ts.postForm(t, "/view/pR0t3ct3dSn1/unlock", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/view/pR0t3ct3dSn1")
+
+		code, _, body = ts.get(t, "/view/pR0t3ct3dSn1")
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "DB_PASSWORD=secret")
+	})
+
+	t.Run("Owner", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		// Log in as the mocked user who owns the mocked snippet.
+		ts.login(t, "alice@example.com")
+
+		// Assert that the owner isn't asked for the password.
+		code, _, body := ts.get(t, "/view/pR0t3ct3dSn1")
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "DB_PASSWORD=secret")
+	})
+}
+
+// TestSnippetEncrypted tests that encrypted snippets are served as ciphertext for the
+// browser to decrypt, and kept out of search engines and history pages.
+func TestSnippetEncrypted(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, headers, body := ts.get(t, "/view/eNcRyPt3dSn1")
+
+	// Assert that the ciphertext is flagged for decryption in the browser.
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, headers.Get("X-Robots-Tag"), "noindex")
+	assert.StringContains(t, body, "data-encrypted>q0Yy3hX8u4d1nGk2YlN0c2Vjcm0tVGhpc0lzQ2lwaGVydGV4dA</code>")
+
+	// Assert that the script highlighting the decrypted snippet in the browser is loaded.
+	assert.StringContains(t, body, `<script src="/static/js/highlight.min.js"></script>`)
+
+	// Assert that the history of encrypted snippets isn't available.
+	code, _, _ = ts.get(t, "/view/eNcRyPt3dSn1/history")
+	assert.Equal(t, code, http.StatusNotFound)
+
+	code, headers, _ = ts.get(t, "/view/aBcD3fGh1jKl")
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, headers.Get("X-Robots-Tag"), "")
+}
+
+// TestSnippetRaw tests the GET /raw/{slug} and GET /download/{slug} endpoints, which send
+// the content of a snippet with the same access rules as the view page.
+func TestSnippetRaw(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name             string // Name of the test case.
+		urlPath          string // URL path to test.
+		wantCode         int    // Expected HTTP status code.
+		wantBody         string // Expected response body (if any).
+		wantCacheControl string // Expected Cache-Control header (if any).
+		wantDisposition  string // Expected Content-Disposition header (if any).
+		wantContentType  string // Expected Content-Type header, if not plain text.
+		wantLocation     string // Expected redirect location (if any).
+	}{
+		{
+			name:             "Raw",
+			urlPath:          "/raw/aBcD3fGh1jKl",
+			wantCode:         http.StatusOK,
+			wantBody:         "console.log();",
+			wantCacheControl: "public, max-age=60",
+		},
+		{
+			name:             "Download",
+			urlPath:          "/download/aBcD3fGh1jKl",
+			wantCode:         http.StatusOK,
+			wantBody:         "console.log();",
+			wantCacheControl: "public, max-age=60",
+			wantDisposition:  "attachment; filename=aBcD3fGh1jKl.js",
+			wantContentType:  "text/javascript; charset=utf-8",
+		},
+		{
+			name:             "Burn after reading",
+			urlPath:          "/raw/bUrN4fTeR5rD",
+			wantCode:         http.StatusOK,
+			wantCacheControl: "no-store",
+		},
+		{
+			name:         "Password protected",
+			urlPath:      "/raw/pR0t3ct3dSn1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/view/pR0t3ct3dSn1",
+		},
+		{
+			name:             "Line range",
+			urlPath:          "/raw/aBcD3fGh1jKl?lines=1-3",
+			wantCode:         http.StatusOK,
+			wantBody:         "console.log();",
+			wantCacheControl: "public, max-age=60",
+		},
+		{
+			name:     "Invalid line range",
+			urlPath:  "/raw/aBcD3fGh1jKl?lines=3-1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Private",
+			urlPath:  "/download/pR1v4t3sN1pP",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:             "Raw main file",
+			urlPath:          "/raw/mUlT1f1l3Sn1/1",
+			wantCode:         http.StatusOK,
+			wantBody:         "FROM golang:1.22",
+			wantCacheControl: "public, max-age=60",
+		},
+		{
+			name:             "Raw additional file",
+			urlPath:          "/raw/mUlT1f1l3Sn1/2",
+			wantCode:         http.StatusOK,
+			wantBody:         "docker build -t app .",
+			wantCacheControl: "public, max-age=60",
+		},
+		{
+			name:             "Download named file",
+			urlPath:          "/download/mUlT1f1l3Sn1/2",
+			wantCode:         http.StatusOK,
+			wantBody:         "docker build -t app .",
+			wantCacheControl: "public, max-age=60",
+			wantDisposition:  "attachment; filename=build.sh",
+			wantContentType:  "application/x-sh; charset=utf-8",
+		},
+		{
+			name:             "Download unnamed file",
+			urlPath:          "/download/mUlT1f1l3Sn1/3",
+			wantCode:         http.StatusOK,
+			wantBody:         "Run **build.sh** from the project root.",
+			wantCacheControl: "public, max-age=60",
+			wantDisposition:  "attachment; filename=mUlT1f1l3Sn1-3.md",
+			wantContentType:  "text/markdown; charset=utf-8",
+		},
+		{
+			name:     "Non-existent file",
+			urlPath:  "/raw/mUlT1f1l3Sn1/4",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid file number",
+			urlPath:  "/raw/mUlT1f1l3Sn1/0",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Non-existent slug",
+			urlPath:  "/raw/xXxXxXxXxXxX",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, headers, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+			assert.Equal(t, headers.Get("Content-Disposition"), tt.wantDisposition)
+
+			if tt.wantCode == http.StatusOK {
+				wantContentType := tt.wantContentType
+				if wantContentType == "" {
+					wantContentType = "text/plain; charset=utf-8"
+				}
+				assert.Equal(t, headers.Get("Content-Type"), wantContentType)
+				assert.Equal(t, headers.Get("Cache-Control"), tt.wantCacheControl)
+			}
+
+			// The raw content must be sent exactly as stored.
+			if tt.wantBody != "" {
+				assert.Equal(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	// Log in as the mocked user who owns the mocked snippets.
+	ts.login(t, "alice@example.com")
+
+	t.Run("Private for the owner", func(t *testing.T) {
+		code, headers, body := ts.get(t, "/download/pR1v4t3sN1pP")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, body, "DEBUG=true")
+		assert.Equal(t, headers.Get("Cache-Control"), "no-store")
+	})
+}
+
+// burnRecorder is a mock snippet model recording the snippets it burns.
+type burnRecorder struct {
+	mocks.SnippetModel
+	burned []int
+}
+
+// Burn records the ID of the burned snippet.
+func (m *burnRecorder) Burn(id int) error {
+	m.burned = append(m.burned, id)
+	return m.SnippetModel.Burn(id)
+}
+
+// TestSnippetRawBurn tests that burn-after-reading snippets are only burned by requests
+// for files which exist.
+func TestSnippetRawBurn(t *testing.T) {
+	app := newTestApplication(t)
+	snippets := &burnRecorder{}
+	app.snippets = snippets
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	for _, urlPath := range []string{"/raw/bUrN4fTeR5rD/9", "/download/bUrN4fTeR5rD/0", "/raw/bUrN4fTeR5rD?lines=0"} {
+		code, _, _ := ts.get(t, urlPath)
+		assert.Equal(t, code != http.StatusOK, true)
+	}
+	assert.Equal(t, len(snippets.burned), 0)
+
+	code, _, _ := ts.get(t, "/raw/bUrN4fTeR5rD/1")
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, len(snippets.burned), 1)
+}
+
+// TestSnippetEmbed tests that the /embed/{slug} endpoint serves a page other sites can
+// frame, and the script injecting it, for snippets which can be shown to anyone.
+func TestSnippetEmbed(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name            string // Name of the test case.
+		urlPath         string // URL path to test.
+		wantCode        int    // Expected HTTP status code.
+		wantBody        string // Expected content in the response body (if any).
+		wantContentType string // Expected Content-Type header, if not HTML.
+	}{
+		{
+			name:     "Embed",
+			urlPath:  "/embed/aBcD3fGh1jKl",
+			wantCode: http.StatusOK,
+			wantBody: `<body class="embed embed-light">`,
+		},
+		{
+			name:     "Dark theme",
+			urlPath:  "/embed/aBcD3fGh1jKl?theme=dark",
+			wantCode: http.StatusOK,
+			wantBody: `<body class="embed embed-dark">`,
+		},
+		{
+			name:     "Additional file",
+			urlPath:  "/embed/mUlT1f1l3Sn1?file=2",
+			wantCode: http.StatusOK,
+			wantBody: `<a href='/view/mUlT1f1l3Sn1#F2-L1' target="_blank" rel="noopener">1</a>`,
+		},
+		{
+			name:     "Line range",
+			urlPath:  "/embed/mUlT1f1l3Sn1?file=3&lines=1-5",
+			wantCode: http.StatusOK,
+			wantBody: "Run <span class=\"hljs-strong\">**build.sh**</span> from the project root.",
+		},
+		{
+			name:            "Script",
+			urlPath:         "/embed/aBcD3fGh1jKl.js?theme=dark",
+			wantCode:        http.StatusOK,
+			wantBody:        "/embed/aBcD3fGh1jKl?theme=dark\"",
+			wantContentType: "text/javascript; charset=utf-8",
+		},
+		{
+			name:     "Invalid theme",
+			urlPath:  "/embed/aBcD3fGh1jKl?theme=blue",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Invalid line range",
+			urlPath:  "/embed/aBcD3fGh1jKl.js?lines=L3",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Non-existent file",
+			urlPath:  "/embed/mUlT1f1l3Sn1?file=4",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Encrypted",
+			urlPath:  "/embed/eNcRyPt3dSn1",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Burn after reading",
+			urlPath:  "/embed/bUrN4fTeR5rD.js",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Password protected",
+			urlPath:  "/embed/pR0t3ct3dSn1",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Private",
+			urlPath:  "/embed/pR1v4t3sN1pP",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Non-existent slug",
+			urlPath:  "/embed/xXxXxXxXxXxX",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, headers, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantCode == http.StatusOK {
+				wantContentType := tt.wantContentType
+				if wantContentType == "" {
+					wantContentType = "text/html; charset=utf-8"
+				}
+				assert.Equal(t, headers.Get("Content-Type"), wantContentType)
+
+				// Only embeds can be framed, by the sites allowed in the configuration.
+				assert.Equal(t, headers.Get("X-Frame-Options"), "")
+				assert.StringContains(t, headers.Get("Content-Security-Policy"), "frame-ancestors *;")
+			}
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	// Log in as the mocked user who owns the mocked snippets.
+	ts.login(t, "alice@example.com")
+
+	t.Run("Private for the owner", func(t *testing.T) {
+		code, _, _ := ts.get(t, "/embed/pR1v4t3sN1pP")
+
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+}
+
+// TestSnippetExplore tests that the /explore endpoint lists public snippets to anyone.
+func TestSnippetExplore(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/explore")
+
+	// Assert that the public snippets are listed along with their author.
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "href='/view/aBcD3fGh1jKl'")
+	assert.StringContains(t, body, "Alice Jones")
+
+	code, _, _ = ts.get(t, "/explore?page=foo")
+
+	// Assert that an invalid page number is rejected.
+	assert.Equal(t, code, http.StatusBadRequest)
+}
+
+// TestSnippetSearch tests the GET /search endpoint, which only finds the snippets the
+// user may see.
+func TestSnippetSearch(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name        string // Name of the test case.
+		urlPath     string // URL path to test.
+		wantCode    int    // Expected HTTP status code.
+		wantBody    string // Expected content in the response body (if any).
+		notWantBody string // Content which must not be in the response body (if any).
+	}{
+		{
+			name:     "Form",
+			urlPath:  "/search",
+			wantCode: http.StatusOK,
+			wantBody: "Any language",
+		},
+		{
+			name:     "Matching snippet",
+			urlPath:  "/search?q=console",
+			wantCode: http.StatusOK,
+			wantBody: "<mark>console</mark>.log();",
+		},
+		{
+			name:     "Language filter",
+			urlPath:  "/search?q=console&lang=go",
+			wantCode: http.StatusOK,
+			wantBody: "No snippets match your search.",
+		},
+		{
+			name:        "Private snippet of another user",
+			urlPath:     "/search?q=debug",
+			wantCode:    http.StatusOK,
+			wantBody:    "No snippets match your search.",
+			notWantBody: "DEBUG=true",
+		},
+		{
+			name:     "Invalid language",
+			urlPath:  "/search?q=console&lang=latin",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Invalid page",
+			urlPath:  "/search?q=console&page=0",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+
+			if tt.notWantBody != "" {
+				assert.Equal(t, strings.Contains(body, tt.notWantBody), false)
+			}
+		})
+	}
+
+	// Log in as the mocked user who owns the private snippet.
+	ts.login(t, "alice@example.com")
+
+	t.Run("Own private snippet", func(t *testing.T) {
+		code, _, body := ts.get(t, "/search?q=debug")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "<mark>DEBUG</mark>=true")
+	})
+
+	t.Run("Additional file", func(t *testing.T) {
+		code, _, body := ts.get(t, "/search?q=docker")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, `<span class="text-gray-400">build.sh:1</span> <mark>docker</mark> build -t app .`)
+	})
+}
+
+// TestSnippetTag tests the GET /tags/{tag} endpoint.
+func TestSnippetTag(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string // Name of the test case.
+		urlPath  string // URL path to test.
+		wantCode int    // Expected HTTP status code.
+		wantBody string // Expected content in the response body (if any).
+	}{
+		{
+			name:     "Tag",
+			urlPath:  "/tags/frontend",
+			wantCode: http.StatusOK,
+			wantBody: "console.log();",
+		},
+		{
+			name:     "Unused tag",
+			urlPath:  "/tags/backend",
+			wantCode: http.StatusOK,
+			wantBody: "No snippets are tagged #backend.",
+		},
+		{
+			name:     "Invalid tag",
+			urlPath:  "/tags/Front%20end",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+// TestCollections tests that users can create collections and add snippets to them.
+func TestCollections(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	t.Run("View", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		code, _, body := ts.get(t, "/collections/cOlL3ct10nAb")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "Deploy runbook commands")
+		assert.StringContains(t, body, "console.log();")
+	})
+
+	t.Run("Non-existent collection", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		code, _, _ := ts.get(t, "/collections/xXxXxXxXxXxX")
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+
+	tests := []struct {
+		name         string // Name of the test case.
+		email        string // Email of the user to log in as.
+		urlPath      string // URL path to test.
+		form         url.Values
+		wantCode     int    // Expected HTTP status code.
+		wantLocation string // Expected redirect location (if any).
+	}{
+		{
+			name:         "Create",
+			email:        "alice@example.com",
+			urlPath:      "/collections",
+			form:         url.Values{"name": {"Deploy runbook commands"}},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/collections/nEwC0ll3ct10",
+		},
+		{
+			name:     "Create without name",
+			email:    "alice@example.com",
+			urlPath:  "/collections",
+			form:     url.Values{"name": {""}},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Add snippet",
+			email:        "alice@example.com",
+			urlPath:      "/collections/add",
+			form:         url.Values{"collection": {"cOlL3ct10nAb"}, "snippet": {"aBcD3fGh1jKl"}},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/view/aBcD3fGh1jKl",
+		},
+		{
+			name:     "Add to another user's collection",
+			email:    "bob@example.com",
+			urlPath:  "/collections/add",
+			form:     url.Values{"collection": {"cOlL3ct10nAb"}, "snippet": {"aBcD3fGh1jKl"}},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Add non-existent snippet",
+			email:    "alice@example.com",
+			urlPath:  "/collections/add",
+			form:     url.Values{"collection": {"cOlL3ct10nAb"}, "snippet": {"xXxXxXxXxXxX"}},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Remove snippet",
+			email:        "alice@example.com",
+			urlPath:      "/collections/cOlL3ct10nAb/snippets/aBcD3fGh1jKl/remove",
+			form:         url.Values{},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/collections/cOlL3ct10nAb",
+		},
+		{
+			name:         "Delete",
+			email:        "alice@example.com",
+			urlPath:      "/collections/cOlL3ct10nAb/delete",
+			form:         url.Values{},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/collections",
+		},
+		{
+			name:     "Delete another user's collection",
+			email:    "bob@example.com",
+			urlPath:  "/collections/cOlL3ct10nAb/delete",
+			form:     url.Values{},
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			ts.login(t, tt.email)
+
+			// Get a valid CSRF token from the collections page.
+			_, _, body := ts.get(t, "/collections")
+			tt.form.Add("csrf_token", extractCSRFToken(t, body))
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, tt.form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+// TestSnippetList tests the /snippets endpoint for anonymous and authenticated users.
+func TestSnippetList(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		// Make a GET request to the /snippets endpoint without logging in.
+		code, headers, _ := ts.get(t, "/snippets")
+
+		// Assert that the user is redirected to the login page.
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/login")
+	})
+
+	// Log in as the mocked user who owns the mocked snippet.
+	ts.login(t, "alice@example.com")
+
+	t.Run("Authenticated", func(t *testing.T) {
+		code, _, body := ts.get(t, "/snippets")
+
+		// Assert that the snippets of the user are listed with a preview.
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "href='/view/aBcD3fGh1jKl'")
+		assert.StringContains(t, body, "console.log();")
+		assert.StringContains(t, body, "JavaScript")
+
+		// Assert that private snippets are listed to their owner.
+		assert.StringContains(t, body, "href='/view/pR1v4t3sN1pP'")
+		assert.StringContains(t, body, "Private")
+	})
+
+	t.Run("Invalid page", func(t *testing.T) {
+		code, _, _ := ts.get(t, "/snippets?page=-1")
+
+		// Assert that an invalid page number is rejected.
+		assert.Equal(t, code, http.StatusBadRequest)
+	})
+}
+
+// TestSnippetCreate tests the /create endpoint with various form submissions.
+func TestSnippetCreate(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	// Log in and get a valid CSRF token from the home page.
+	ts.login(t, "alice@example.com")
+	_, _, body := ts.get(t, "/")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	// Assert that the language is detected by default.
+	assert.StringContains(t, body, "<option value='auto' selected>Auto-detect</option>")
+
+	tests := []struct {
+		name         string     // Name of the test case.
+		content      string     // Snippet content to test.
+		language     string     // Snippet language to test.
+		expires      string     // Snippet expiration to test.
+		visibility   string     // Snippet visibility to test.
+		password     string     // Snippet password to test.
+		encrypted    string     // Snippet encryption flag to test.
+		tags         string     // Snippet tags to test.
+		title        string     // Snippet title to test.
+		description  string     // Snippet description to test.
+		files        url.Values // Additional files to test.
+		wantCode     int        // Expected HTTP status code.
+		wantLocation string     // Expected redirect location (if any).
+	}{
+		{
+			name:         "Valid submission",
+			content:      "console.log();",
+			language:     "javascript",
+			expires:      "never",
+			visibility:   "unlisted",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/view/nEwSn1pP3t0o",
+		},
+		{
+			name:         "Detected language",
+			content:      "console.log();",
+			language:     "auto",
+			expires:      "never",
+			visibility:   "unlisted",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/view/nEwSn1pP3t0o",
+		},
+		{
+			name:         "Language alias",
+			content:      "console.log();",
+			language:     "JS",
+			expires:      "never",
+			visibility:   "unlisted",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/view/nEwSn1pP3t0o",
+		},
+		{
+			name:         "Burn after reading",
+			content:      "console.log();",
+			language:     "javascript",
+			expires:      "burn",
+			visibility:   "unlisted",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/view/nEwSn1pP3t0o",
+		},
+		{
+			name:         "Password",
+			content:      "API_KEY=abc",
+			language:     "plaintext",
+			expires:      "never",
+			visibility:   "unlisted",
+			password:     "opensesame",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/view/nEwSn1pP3t0o",
+		},
+		{
+			name:       "Password too long",
+			content:    "API_KEY=abc",
+			language:   "plaintext",
+			expires:    "never",
+			visibility: "unlisted",
+			password:   strings.Repeat("a", 73),
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Encrypted",
+			content:      "ujMn9dorDgvaIz2ZZI8AxIOxGFO87baNxs1jRcvLTVppT9Ufo51tew",
+			language:     "plaintext",
+			expires:      "never",
+			visibility:   "unlisted",
+			encrypted:    "true",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/view/nEwSn1pP3t0o",
+		},
+		{
+			name:       "Encrypted plaintext",
+			content:    "console.log();",
+			language:   "javascript",
+			expires:    "never",
+			visibility: "unlisted",
+			encrypted:  "true",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Tags",
+			content:      "docker compose up -d",
+			language:     "bash",
+			expires:      "never",
+			visibility:   "unlisted",
+			tags:         "Deploy, docker deploy",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/view/nEwSn1pP3t0o",
+		},
+		{
+			name:       "Too many tags",
+			content:    "docker compose up -d",
+			language:   "bash",
+			expires:    "never",
+			visibility: "unlisted",
+			tags:       "a b c d e f g h i j k",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Invalid tag",
+			content:    "docker compose up -d",
+			language:   "bash",
+			expires:    "never",
+			visibility: "unlisted",
+			tags:       "c#",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Title and description",
+			content:      "docker compose up -d",
+			language:     "bash",
+			expires:      "never",
+			visibility:   "unlisted",
+			title:        "Start the stack",
+			description:  "Runs every service in the **background**.",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/view/nEwSn1pP3t0o",
+		},
+		{
+			name:       "Multiple files",
+			content:    "FROM golang:1.22",
+			language:   "plaintext",
+			expires:    "never",
+			visibility: "unlisted",
+			files: url.Values{
+				"filename":          {"Dockerfile"},
+				"files[0].filename": {"build.sh"},
+				"files[0].language": {"bash"},
+				"files[0].content":  {"docker build -t app ."},
+				"files[1].language": {"plaintext"},
+				"files[1].content":  {"Run build.sh from the project root."},
+			},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/view/nEwSn1pP3t0o",
+		},
+		{
+			name:       "Blank additional file",
+			content:    "FROM golang:1.22",
+			language:   "plaintext",
+			expires:    "never",
+			visibility: "unlisted",
+			files: url.Values{
+				"files[0].language": {"bash"},
+				"files[0].content":  {" "},
+			},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Duplicate filenames",
+			content:    "FROM golang:1.22",
+			language:   "plaintext",
+			expires:    "never",
+			visibility: "unlisted",
+			files: url.Values{
+				"filename":          {"build.sh"},
+				"files[0].filename": {"build.sh"},
+				"files[0].language": {"bash"},
+				"files[0].content":  {"docker build -t app ."},
+			},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Invalid filename",
+			content:    "FROM golang:1.22",
+			language:   "plaintext",
+			expires:    "never",
+			visibility: "unlisted",
+			files:      url.Values{"filename": {"../Dockerfile"}},
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Title too long",
+			content:    "docker compose up -d",
+			language:   "bash",
+			expires:    "never",
+			visibility: "unlisted",
+			title:      strings.Repeat("a", 101),
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Description too long",
+			content:     "docker compose up -d",
+			language:    "bash",
+			expires:     "never",
+			visibility:  "unlisted",
+			description: strings.Repeat("a", 2001),
+			wantCode:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Empty content",
+			content:  "",
+			language: "javascript",
+			expires:  "never",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Invalid language",
+			content:  "console.log();",
+			language: "latin",
+			expires:  "never",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Invalid visibility",
+			content:    "console.log();",
+			language:   "javascript",
+			expires:    "never",
+			visibility: "secret",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Invalid expiration",
+			content:  "console.log();",
+			language: "javascript",
+			expires:  "1y",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("content", tt.content)
+			form.Add("language", tt.language)
+			form.Add("expires", tt.expires)
+			form.Add("visibility", tt.visibility)
+			form.Add("password", tt.password)
+			form.Add("encrypted", tt.encrypted)
+			form.Add("tags", tt.tags)
+			form.Add("title", tt.title)
+			form.Add("description", tt.description)
+			form.Add("csrf_token", validCSRFToken)
+			for key, values := range tt.files {
+				form[key] = values
+			}
+
+			code, headers, _ := ts.postForm(t, "/create", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+// TestSnippetFork tests the /fork/{slug} endpoint, and the creation of the fork.
+func TestSnippetFork(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	t.Run("Anonymous", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		// Assert that anonymous users are asked to log in first.
+		code, headers, _ := ts.get(t, "/fork/aBcD3fGh1jKl")
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/login")
+	})
+
+	t.Run("Authenticated", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		// Log in as a mocked user who doesn't own the mocked snippet.
+		ts.login(t, "bob@example.com")
+
+		// Assert that the home page form is prefilled with a copy of the snippet.
+		code, _, body := ts.get(t, "/fork/aBcD3fGh1jKl")
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "You are forking <a class=\"font-medium text-gray-950 hover:text-gray-400\" href='/view/aBcD3fGh1jKl'>Log to the console</a>")
+		assert.StringContains(t, body, "<input type='hidden' name='forked_from' value='aBcD3fGh1jKl'>")
+		assert.StringContains(t, body, "console.log();</textarea>")
+		assert.StringContains(t, body, "<option value='javascript' selected>")
+
+		csrfToken := extractCSRFToken(t, body)
+
+		// Assert that an invalid submission keeps the reference to the original snippet.
+		form := url.Values{}
+		form.Add("content", "")
+		form.Add("language", "javascript")
+		form.Add("expires", "never")
+		form.Add("visibility", "unlisted")
+		form.Add("forked_from", "aBcD3fGh1jKl")
+		form.Add("csrf_token", csrfToken)
+
+		code, _, body = ts.postForm(t, "/create", form)
+		assert.Equal(t, code, http.StatusUnprocessableEntity)
+		assert.StringContains(t, body, "<input type='hidden' name='forked_from' value='aBcD3fGh1jKl'>")
+
+		// Assert that a valid submission creates the fork.
+		form.Set("content", "console.log(1);")
+
+		code, headers, _ := ts.postForm(t, "/create", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/view/nEwSn1pP3t0o")
+
+		// Assert that snippets which can't be forked are not found.
+		for _, slug := range []string{"eNcRyPt3dSn1", "bUrN4fTeR5rD", "pR1v4t3sN1pP", "xXxXxXxXxXxX"} {
+			code, _, _ = ts.get(t, "/fork/"+slug)
+			assert.Equal(t, code, http.StatusNotFound)
+		}
+
+		// Assert that locked snippets must be unlocked first.
+		code, headers, _ = ts.get(t, "/fork/pR0t3ct3dSn1")
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/view/pR0t3ct3dSn1")
+	})
+}
+
+// TestSnippetHistory tests the /view/{slug}/history and /view/{slug}/rev/{n} endpoints.
+func TestSnippetHistory(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string // Name of the test case.
+		urlPath  string // URL path to test.
+		wantCode int    // Expected HTTP status code.
+		wantBody string // Expected response body (if any).
+	}{
+		{
+			name:     "History",
+			urlPath:  "/view/aBcD3fGh1jKl/history",
+			wantCode: http.StatusOK,
+			wantBody: "href='/view/aBcD3fGh1jKl/rev/1'",
+		},
+		{
+			name:     "History of non-existent snippet",
+			urlPath:  "/view/xXxXxXxXxXxX/history",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "History of burn-after-reading snippet",
+			urlPath:  "/view/bUrN4fTeR5rD/history",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Valid revision",
+			urlPath:  "/view/aBcD3fGh1jKl/rev/1",
+			wantCode: http.StatusOK,
+			wantBody: "You are viewing revision 1 of this snippet.",
+		},
+		{
+			name:     "Multi-file revision",
+			urlPath:  "/view/mUlT1f1l3Sn1/rev/1",
+			wantCode: http.StatusOK,
+			wantBody: "docker build .",
+		},
+		{
+			name:     "Non-existent revision",
+			urlPath:  "/view/aBcD3fGh1jKl/rev/3",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "String revision",
+			urlPath:  "/view/aBcD3fGh1jKl/rev/foo",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	// Iterate over the test cases.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	// Files added after a revision must not be shown on its page.
+	t.Run("Files of a revision", func(t *testing.T) {
+		_, _, body := ts.get(t, "/view/mUlT1f1l3Sn1/rev/1")
+
+		assert.Equal(t, strings.Contains(body, "from the project root"), false)
+	})
+}
+
+// TestSnippetDiff tests the /view/{slug}/diff endpoint with various revision ranges.
+func TestSnippetDiff(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string   // Name of the test case.
+		urlPath  string   // URL path to test.
+		wantCode int      // Expected HTTP status code.
+		wantBody []string // Expected fragments of the response body (if any).
+	}{
+		{
+			name:     "Latest changes",
+			urlPath:  "/view/aBcD3fGh1jKl/diff",
+			wantCode: http.StatusOK,
+			wantBody: []string{
+				"@@ -1,1 &#43;1,1 @@",
+				"<span class=\"block px-4 bg-red-100\">-alert();</span>",
+				"<span class=\"block px-4 bg-green-100\">&#43;console.log();</span>",
+			},
+		},
+		{
+			name:     "Changes to several files",
+			urlPath:  "/view/mUlT1f1l3Sn1/diff",
+			wantCode: http.StatusOK,
+			wantBody: []string{
+				"<span class=\"font-medium text-gray-950 break-words\">build.sh</span>",
+				"<span class=\"block px-4 bg-red-100\">-docker build .</span>",
+				"<span class=\"block px-4 bg-green-100\">&#43;docker build -t app .</span>",
+				"<span class=\"font-medium text-gray-950 break-words\">File 3</span>",
+				"<span class=\"text-gray-500\">Added</span>",
+			},
+		},
+		{
+			name:     "Same revision",
+			urlPath:  "/view/aBcD3fGh1jKl/diff?from=2&to=2",
+			wantCode: http.StatusOK,
+			wantBody: []string{"There are no changes between these revisions."},
+		},
+		{
+			name:     "Non-existent revision",
+			urlPath:  "/view/aBcD3fGh1jKl/diff?from=1&to=3",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid revision",
+			urlPath:  "/view/aBcD3fGh1jKl/diff?from=foo",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Non-existent snippet",
+			urlPath:  "/view/xXxXxXxXxXxX/diff",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	// Iterate over the test cases.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			for _, want := range tt.wantBody {
+				assert.StringContains(t, body, want)
+			}
+		})
+	}
+}
+
+// TestSnippetEdit tests the /edit/{slug} endpoint for the owner of a snippet and other users.
+func TestSnippetEdit(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	t.Run("Owner", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		// Log in as the mocked user who owns the mocked snippet.
+		ts.login(t, "alice@example.com")
+
+		// Assert that the edit form is prefilled with the snippet content.
+		code, _, body := ts.get(t, "/edit/aBcD3fGh1jKl")
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "console.log();</textarea>")
+		assert.StringContains(t, body, "<option value='javascript' selected>")
+
+		csrfToken := extractCSRFToken(t, body)
+
+		// Assert that an invalid submission re-displays the form.
+		form := url.Values{}
+		form.Add("content", "")
+		form.Add("language", "javascript")
+		form.Add("visibility", "private")
+		form.Add("csrf_token", csrfToken)
+
+		code, _, body = ts.postForm(t, "/edit/aBcD3fGh1jKl", form)
+		assert.Equal(t, code, http.StatusUnprocessableEntity)
+		assert.StringContains(t, body, "This field cannot be blank")
+
+		// Assert that a valid submission redirects to the snippet.
+		form.Set("content", "console.log(1);")
+
+		code, headers, _ := ts.postForm(t, "/edit/aBcD3fGh1jKl", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/view/aBcD3fGh1jKl")
+	})
+
+	t.Run("Not the owner", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		// Log in as a mocked user who doesn't own the mocked snippet.
+		ts.login(t, "bob@example.com")
+
+		code, _, _ := ts.get(t, "/edit/aBcD3fGh1jKl")
+		assert.Equal(t, code, http.StatusForbidden)
+	})
+}
+
+// TestSnippetDelete tests the /delete/{slug} endpoint for the owner of a snippet and other users.
+func TestSnippetDelete(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name         string // Name of the test case.
+		email        string // Email of the user to log in as.
+		urlPath      string // URL path to test.
+		wantCode     int    // Expected HTTP status code.
+		wantLocation string // Expected redirect location (if any).
+	}{
+		{
+			name:         "Owner",
+			email:        "alice@example.com",
+			urlPath:      "/delete/aBcD3fGh1jKl",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippets",
+		},
+		{
+			name:     "Not the owner",
+			email:    "bob@example.com",
+			urlPath:  "/delete/aBcD3fGh1jKl",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent slug",
+			email:    "alice@example.com",
+			urlPath:  "/delete/xXxXxXxXxXxX",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			ts.login(t, tt.email)
+
+			// Get a valid CSRF token from the home page.
+			_, _, body := ts.get(t, "/")
+
+			form := url.Values{}
+			form.Add("csrf_token", extractCSRFToken(t, body))
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+// TestTokens tests that users can create and revoke their personal API tokens.
+func TestTokens(t *testing.T) {
+	// Create a new instance of our application struct which uses the mocked dependencies.
+	app := newTestApplication(t)
+
+	// Establish a new test server for running end-to-end tests.
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/account/tokens")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/login")
+	})
+
+	ts.login(t, "alice@example.com")
+
+	// Get the list of tokens and a valid CSRF token.
+	code, _, body := ts.get(t, "/account/tokens")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "Editor plugin")
+
+	csrfToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string // Name of the test case.
+		urlPath      string // URL path to test.
+		tokenName    string // Token name to submit.
+		scope        string // Token scope to submit.
+		wantCode     int    // Expected HTTP status code.
+		wantBody     string // Expected content in the response body (if any).
+		wantLocation string // Expected redirect location (if any).
+	}{
+		{
+			name:         "Create token",
+			urlPath:      "/account/tokens",
+			tokenName:    "Deploy script",
+			scope:        "write",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/account/tokens",
+		},
+		{
+			name:     "Blank name",
+			urlPath:  "/account/tokens",
+			scope:    "read",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field cannot be blank",
+		},
+		{
+			name:      "Invalid scope",
+			urlPath:   "/account/tokens",
+			tokenName: "Deploy script",
+			scope:     "admin",
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  "This field must be a valid scope",
+		},
+		{
+			name:         "Revoke token",
+			urlPath:      "/account/tokens/1/revoke",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/account/tokens",
+		},
+		{
+			name:     "Revoke non-existent token",
+			urlPath:  "/account/tokens/2/revoke",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Revoke invalid ID",
+			urlPath:  "/account/tokens/foo/revoke",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("name", tt.tokenName)
+			form.Add("scope", tt.scope)
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, body := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("New token shown once", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("name", "Deploy script")
+		form.Add("scope", "write")
+		form.Add("csrf_token", csrfToken)
+
+		code, _, _ := ts.postForm(t, "/account/tokens", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+
+		_, _, body := ts.get(t, "/account/tokens")
+		assert.StringContains(t, body, "ssnipp_nEwT0k3nNewToken00000000000000000")
+
+		// Reloading the page neither shows the token again nor creates another one
+		_, _, body = ts.get(t, "/account/tokens")
+		assert.Equal(t, strings.Contains(body, "ssnipp_nEwT0k3nNewToken00000000000000000"), false)
+	})
 }
 
 // TestUserSignup tests the /signup endpoint with various form submissions to check for proper handling.
@@ -2,14 +2,21 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
+	"regexp"
 	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/form/v4"
 	"github.com/justinas/nosurf"
+	"ssnipp.com/internal/diff"
+	"ssnipp.com/internal/models"
 )
 
 // serverError logs the detailed error message and stack trace, then sends a generic 500 Internal Server Error response to the user.
@@ -74,11 +81,13 @@ func (app *application) render(w http.ResponseWriter, r *http.Request, status in
 // flash message, authentication status, signup allowance, and CSRF token.
 func (app *application) newTemplateData(r *http.Request) templateData {
 	return templateData{
-		CurrentYear:     time.Now().Year(),
-		Flash:           app.sessionManager.PopString(r.Context(), "flash"),
-		IsAuthenticated: app.isAuthenticated(r),
-		AllowSignup:     app.allowSignup,
-		CSRFToken:       nosurf.Token(r),
+		CurrentYear:         time.Now().Year(),
+		Flash:               app.sessionManager.PopString(r.Context(), "flash"),
+		IsAuthenticated:     app.isAuthenticated(r),
+		AuthenticatedUserID: app.authenticatedUserID(r),
+		AllowSignup:         app.allowSignup,
+		ExampleSnippet:      app.exampleSnippet,
+		CSRFToken:           nosurf.Token(r),
 	}
 }
 
@@ -118,3 +127,411 @@ func (app *application) isAuthenticated(r *http.Request) bool {
 
 	return isAuthenticated
 }
+
+// authenticatedUserID returns the ID of the user making the current request, as added to
+// the request context by the authentication middleware. It returns 0 if the user isn't
+// authenticated.
+func (app *application) authenticatedUserID(r *http.Request) int {
+	id, ok := r.Context().Value(authenticatedUserIDContextKey).(int)
+	if !ok {
+		return 0
+	}
+
+	return id
+}
+
+// readPositiveInt reads an integer from the given query string parameter, such as a
+// page number. It returns defaultValue if the parameter is missing, and an error if
+// it isn't a positive integer.
+func readPositiveInt(r *http.Request, key string, defaultValue int) (int, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 1 {
+		return 0, fmt.Errorf("invalid %s parameter", key)
+	}
+
+	return i, nil
+}
+
+// lineRange is a range of lines of a file, numbered from 1, including both ends. The
+// zero value selects the whole file.
+type lineRange struct {
+	start, end int
+}
+
+// lineRangeRX matches the ranges of lines given in query strings, either a single line
+// such as 12 or a range such as 12-30.
+var lineRangeRX = regexp.MustCompile(`^(\d+)(?:-(\d+))?$`)
+
+// readLineRange reads a range of lines from the given query string parameter. It returns
+// the zero lineRange if the parameter is missing, and an error if it isn't a valid range
+// of positive line numbers.
+func readLineRange(r *http.Request, key string) (lineRange, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return lineRange{}, nil
+	}
+
+	m := lineRangeRX.FindStringSubmatch(s)
+	if m == nil {
+		return lineRange{}, fmt.Errorf("invalid %s parameter", key)
+	}
+
+	start, err := strconv.Atoi(m[1])
+	if err != nil || start < 1 {
+		return lineRange{}, fmt.Errorf("invalid %s parameter", key)
+	}
+
+	end := start
+	if m[2] != "" {
+		end, err = strconv.Atoi(m[2])
+		if err != nil || end < start {
+			return lineRange{}, fmt.Errorf("invalid %s parameter", key)
+		}
+	}
+
+	return lineRange{start: start, end: end}, nil
+}
+
+// selectLines returns the lines of the content within the range, including their line
+// breaks. Ranges reaching past the end of the content are cut short.
+func selectLines(content string, lines lineRange) string {
+	if lines == (lineRange{}) {
+		return content
+	}
+
+	start, end := -1, len(content)
+	number := 1
+	for i := 0; i <= len(content); i++ {
+		if number == lines.start && start < 0 {
+			start = i
+		}
+		if i == len(content) {
+			break
+		}
+		if content[i] == '\n' {
+			if number == lines.end {
+				end = i + 1
+				break
+			}
+			number++
+		}
+	}
+
+	if start < 0 {
+		return ""
+	}
+	return content[start:end]
+}
+
+// slugRX matches the format of the random slugs identifying snippets in URLs.
+var slugRX = regexp.MustCompile(`^[A-Za-z0-9_-]{10,16}$`)
+
+// snippetFromSlug retrieves the snippet with the given slug, as visible to the user making
+// the request. It returns models.ErrNoRecord if the slug is invalid, no matching snippet
+// exists, or the snippet is private and doesn't belong to the authenticated user.
+func (app *application) snippetFromSlug(r *http.Request, slug string) (models.Snippet, error) {
+	if !slugRX.MatchString(slug) {
+		return models.Snippet{}, models.ErrNoRecord
+	}
+
+	// Retrieve the snippet from the database
+	snippet, err := app.snippets.GetBySlug(slug)
+	if err != nil {
+		return models.Snippet{}, err
+	}
+
+	// Private snippets are hidden from everyone but their owner, without revealing
+	// that they exist.
+	if snippet.Visibility == models.VisibilityPrivate && !app.isOwner(r, snippet) {
+		return models.Snippet{}, models.ErrNoRecord
+	}
+
+	return snippet, nil
+}
+
+// snippetFromPath retrieves the snippet identified by the "slug" wildcard of the request path,
+// like snippetFromSlug. If the snippet can't be found, it sends a 404 Not Found response, and
+// a 500 Internal Server Error response for any other error. The boolean result reports
+// whether the snippet was found, in which case the caller can carry on handling the request.
+func (app *application) snippetFromPath(w http.ResponseWriter, r *http.Request) (models.Snippet, bool) {
+	snippet, err := app.snippetFromSlug(r, r.PathValue("slug"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return models.Snippet{}, false
+	}
+
+	return snippet, true
+}
+
+// burnOnRead deletes a burn-after-reading snippet which is being read by someone other
+// than its owner. It returns models.ErrNoRecord if another reader got it first, in which
+// case the snippet must not be shown.
+func (app *application) burnOnRead(r *http.Request, snippet models.Snippet) error {
+	if !snippet.BurnAfterRead || app.isOwner(r, snippet) {
+		return nil
+	}
+
+	return app.snippets.Burn(snippet.ID)
+}
+
+// isOwner reports whether the snippet belongs to the authenticated user.
+func (app *application) isOwner(r *http.Request, snippet models.Snippet) bool {
+	return app.isAuthenticated(r) && snippet.UserID == app.authenticatedUserID(r)
+}
+
+// isListed reports whether the snippet can be listed to the authenticated user, alongside
+// other snippets, such as in collections: their own snippets, and the public snippets
+// listed on the explore page.
+func (app *application) isListed(r *http.Request, snippet models.Snippet) bool {
+	if app.isOwner(r, snippet) {
+		return true
+	}
+
+	return snippet.Visibility == models.VisibilityPublic && !snippet.BurnAfterRead && !snippet.HasPassword() && !snippet.Encrypted
+}
+
+// isForkable reports whether the snippet can be copied into a new snippet by the user
+// making the request, provided it is unlocked. The content of encrypted snippets can't be
+// read on the server, and burn-after-reading snippets can only be forked by their owner,
+// as they are deleted as soon as someone else reads them.
+func (app *application) isForkable(r *http.Request, snippet models.Snippet) bool {
+	return !snippet.Encrypted && (!snippet.BurnAfterRead || app.isOwner(r, snippet))
+}
+
+// isEmbeddable reports whether the snippet can be embedded in other sites. Embedded
+// snippets are shown to anyone visiting these sites, so they can't be private or ask for
+// a password, burn-after-reading snippets would be burned by the first visit, and
+// encrypted ones can't be highlighted without the key.
+func isEmbeddable(snippet models.Snippet) bool {
+	return snippet.Visibility != models.VisibilityPrivate && !snippet.Encrypted &&
+		!snippet.BurnAfterRead && !snippet.HasPassword()
+}
+
+// unlockSessionKey returns the session key recording that the password of the snippet
+// with the given slug was entered.
+func unlockSessionKey(slug string) string {
+	return "unlocked:" + slug
+}
+
+// isUnlocked reports whether the content of the snippet can be shown. This is always
+// the case for snippets without a password and for their owner. Otherwise, the password
+// must have been entered earlier in the session.
+func (app *application) isUnlocked(r *http.Request, snippet models.Snippet) bool {
+	if !snippet.HasPassword() || app.isOwner(r, snippet) {
+		return true
+	}
+
+	return app.sessionManager.GetBool(r.Context(), unlockSessionKey(snippet.Slug))
+}
+
+// snippetHistoryFromPath retrieves the snippet identified in the URL like snippetFromPath,
+// for handlers which show its past revisions. The history of burn-after-reading snippets
+// is only available to their owner, as it would otherwise be a way to read them without
+// burning them, and encrypted snippets have no history to show, as their revisions can't
+// be compared on the server. Visitors of locked snippets are redirected to the unlock form.
+func (app *application) snippetHistoryFromPath(w http.ResponseWriter, r *http.Request) (models.Snippet, bool) {
+	snippet, ok := app.snippetFromPath(w, r)
+	if !ok {
+		return models.Snippet{}, false
+	}
+
+	if (snippet.BurnAfterRead && !app.isOwner(r, snippet)) || snippet.Encrypted {
+		http.NotFound(w, r)
+		return models.Snippet{}, false
+	}
+
+	if !app.isUnlocked(r, snippet) {
+		http.Redirect(w, r, fmt.Sprintf("/view/%s", snippet.Slug), http.StatusSeeOther)
+		return models.Snippet{}, false
+	}
+
+	return snippet, true
+}
+
+// highlightCacheSize is the number of snippet files whose highlighted HTML is kept in
+// memory.
+const highlightCacheSize = 1000
+
+// highlightFiles returns the highlighted HTML of the files of the snippet, in the order of
+// AllFiles, as it is now or, for revision pages, as it was at the given revision. Each
+// revision is cached separately, so that viewing one doesn't evict the current version.
+// Encrypted snippets can only be highlighted in the browser once decrypted, so nothing
+// is returned for them.
+func (app *application) highlightFiles(snippet models.Snippet, revision int) []template.HTML {
+	if snippet.Encrypted {
+		return nil
+	}
+
+	files := snippet.AllFiles()
+	highlighted := make([]template.HTML, len(files))
+	for i, f := range files {
+		highlighted[i] = app.highlighter.HTML(fmt.Sprintf("%d/%d/%d", snippet.ID, revision, i+1), f.Content, getLanguageHighlight(f.Language))
+	}
+
+	return highlighted
+}
+
+// renderFiles returns the HTML rendered from the Markdown files of the snippet, in the
+// order of AllFiles, leaving the other files empty. It is cached like the highlighted
+// HTML of highlightFiles. Encrypted snippets can't be rendered on the server, so nothing
+// is returned for them.
+func (app *application) renderFiles(snippet models.Snippet, revision int) []template.HTML {
+	if snippet.Encrypted {
+		return nil
+	}
+
+	files := snippet.AllFiles()
+	rendered := make([]template.HTML, len(files))
+	for i, f := range files {
+		if f.Language == "markdown" {
+			key := fmt.Sprintf("%d/%d/%d/rendered", snippet.ID, revision, i+1)
+			rendered[i] = app.highlighter.Render(key, f.Content, f.Language, renderMarkdown)
+		}
+	}
+
+	return rendered
+}
+
+// renderMarkdown renders Markdown code, for the highlight cache.
+func renderMarkdown(code, _ string) template.HTML {
+	return markdown(code)
+}
+
+// maxDiffSize and maxDiffLines limit the size of the files compared on diff pages, as
+// the differences between larger ones would take too long to compute and to read.
+const (
+	maxDiffSize  = 1 << 20
+	maxDiffLines = 10000
+)
+
+// diffFiles returns the differences between the files of two revisions, leaving out
+// the files which didn't change. Files are compared by position, as in AllFiles, and
+// those too large to compare are only marked as such.
+func diffFiles(from, to models.Revision) []fileDiff {
+	oldFiles, newFiles := from.AllFiles(), to.AllFiles()
+
+	// Files are only named when there are several, or when the main file has a name
+	named := len(oldFiles) > 1 || len(newFiles) > 1 || from.Filename != "" || to.Filename != ""
+
+	var files []fileDiff
+	for i := range max(len(oldFiles), len(newFiles)) {
+		var oldFile, newFile models.File
+		var oldName, newName string
+		if i < len(oldFiles) {
+			oldFile = oldFiles[i]
+			oldName = cmp.Or(oldFile.Filename, fmt.Sprintf("File %d", i+1))
+		}
+		if i < len(newFiles) {
+			newFile = newFiles[i]
+			newName = cmp.Or(newFile.Filename, fmt.Sprintf("File %d", i+1))
+		}
+
+		var f fileDiff
+		if len(oldFile.Content)+len(newFile.Content) > maxDiffSize ||
+			strings.Count(oldFile.Content, "\n")+strings.Count(newFile.Content, "\n") > maxDiffLines {
+			f.TooLarge = oldFile.Content != newFile.Content
+		} else {
+			f.Hunks = diff.Unified(oldFile.Content, newFile.Content, 3)
+		}
+
+		if named {
+			switch {
+			case oldName == "":
+				f.Name, f.Note = newName, "Added"
+			case newName == "":
+				f.Name, f.Note = oldName, "Removed"
+			case oldName != newName:
+				f.Name, f.Note = newName, "Renamed from "+oldName
+			default:
+				f.Name = newName
+			}
+		}
+
+		if len(f.Hunks) > 0 || f.TooLarge || f.Note != "" {
+			files = append(files, f)
+		}
+	}
+
+	return files
+}
+
+// rawCacheMaxAge is how long the raw content of shareable snippets can be cached, kept
+// short so that edits and deletions show up quickly.
+const rawCacheMaxAge = time.Minute
+
+// snippetContentFromPath retrieves the snippet identified in the URL like snippetFromPath,
+// and its file like snippetFileFromPath, for handlers which send its raw content. It
+// applies the same access rules as the snippet view page: visitors of locked snippets are
+// redirected to the unlock form, and burn-after-reading snippets are burned, only once
+// the file is known to exist. It also sets the cache headers of the response, so that
+// only snippets which look the same to everyone can be stored by shared caches.
+func (app *application) snippetContentFromPath(w http.ResponseWriter, r *http.Request) (models.Snippet, models.File, int, bool) {
+	snippet, ok := app.snippetFromPath(w, r)
+	if !ok {
+		return models.Snippet{}, models.File{}, 0, false
+	}
+
+	if !app.isUnlocked(r, snippet) {
+		http.Redirect(w, r, fmt.Sprintf("/view/%s", snippet.Slug), http.StatusSeeOther)
+		return models.Snippet{}, models.File{}, 0, false
+	}
+
+	file, number, ok := app.snippetFileFromPath(w, r, snippet)
+	if !ok {
+		return models.Snippet{}, models.File{}, 0, false
+	}
+
+	// Keep encrypted snippets out of search engines, as their content is meaningless
+	// without the key
+	if snippet.Encrypted {
+		w.Header().Set("X-Robots-Tag", "noindex")
+	}
+
+	err := app.burnOnRead(r, snippet)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return models.Snippet{}, models.File{}, 0, false
+	}
+
+	if snippet.Visibility == models.VisibilityPrivate || snippet.HasPassword() || snippet.BurnAfterRead {
+		w.Header().Set("Cache-Control", "no-store")
+	} else {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(rawCacheMaxAge.Seconds())))
+	}
+
+	return snippet, file, number, true
+}
+
+// snippetFileFromPath returns the file of the snippet identified by the "file" wildcard of
+// the request path, numbered from 1 for the main file, along with its number. The main
+// file is returned if the path has no "file" wildcard. If the snippet has no such file, it
+// sends a 404 Not Found response, and the boolean result is false.
+func (app *application) snippetFileFromPath(w http.ResponseWriter, r *http.Request, snippet models.Snippet) (models.File, int, bool) {
+	files := snippet.AllFiles()
+
+	value := r.PathValue("file")
+	if value == "" {
+		return files[0], 1, true
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 || number > len(files) {
+		http.NotFound(w, r)
+		return models.File{}, 0, false
+	}
+
+	return files[number-1], number, true
+}
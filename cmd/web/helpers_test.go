@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ssnipp.com/internal/assert"
+	"ssnipp.com/internal/models"
+)
+
+// TestSelectLines tests that ranges of lines are read from query strings and cut out of
+// the content of files.
+func TestSelectLines(t *testing.T) {
+	content := "one\ntwo\nthree\n"
+
+	tests := []struct {
+		name    string // Name of the test case.
+		query   string // Query string of the request.
+		want    string // Expected lines.
+		wantErr bool   // Whether the range should be rejected.
+	}{
+		{name: "All lines", query: "", want: content},
+		{name: "Single line", query: "lines=2", want: "two\n"},
+		{name: "Range", query: "lines=1-2", want: "one\ntwo\n"},
+		{name: "Past the end", query: "lines=3-10", want: "three\n"},
+		{name: "After the end", query: "lines=5", want: ""},
+		{name: "Zero", query: "lines=0", wantErr: true},
+		{name: "Reversed", query: "lines=2-1", wantErr: true},
+		{name: "Not a number", query: "lines=L2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/raw/aBcD3fGh1jKl?"+tt.query, nil)
+
+			lines, err := readLineRange(r, "lines")
+			assert.Equal(t, err != nil, tt.wantErr)
+			if err == nil {
+				assert.Equal(t, selectLines(content, lines), tt.want)
+			}
+		})
+	}
+
+	assert.Equal(t, selectLines("one\ntwo", lineRange{start: 2, end: 2}), "two")
+}
+
+// TestHighlightFiles tests that revisions of a snippet are cached apart from its current
+// version, so that viewing them doesn't evict it.
+func TestHighlightFiles(t *testing.T) {
+	app := newTestApplication(t)
+
+	snippet := models.Snippet{ID: 1, Content: "nil", Language: "go"}
+	revision := models.Snippet{ID: 1, Content: "true", Language: "go"}
+
+	app.highlightFiles(snippet, 0)
+	assert.Equal(t, app.highlightFiles(revision, 1)[0], `<span class="hljs-literal">true</span>`)
+	assert.Equal(t, app.highlightFiles(snippet, 0)[0], `<span class="hljs-literal">nil</span>`)
+	assert.Equal(t, app.highlighter.Len(), 2)
+
+	assert.Equal(t, len(app.highlightFiles(models.Snippet{ID: 2, Encrypted: true}, 0)), 0)
+}
+
+// TestRenderFiles tests that only Markdown files are rendered, and that the rendered
+// HTML is cached with the highlighted HTML.
+func TestRenderFiles(t *testing.T) {
+	app := newTestApplication(t)
+
+	snippet := models.Snippet{
+		ID:       1,
+		Content:  "nil",
+		Language: "go",
+		Files:    []models.File{{Language: "markdown", Content: "**x**"}},
+	}
+
+	rendered := app.renderFiles(snippet, 0)
+	assert.Equal(t, len(rendered), 2)
+	assert.Equal(t, rendered[0], "")
+	assert.Equal(t, rendered[1], "<p><strong>x</strong></p>\n")
+	assert.Equal(t, app.highlighter.Len(), 1)
+
+	app.renderFiles(snippet, 0)
+	assert.Equal(t, app.highlighter.Len(), 1)
+}
+
+// TestDiffFiles tests that only the files which changed between two revisions are
+// compared, and that they are named when the snippets have several files.
+func TestDiffFiles(t *testing.T) {
+	from := models.Revision{
+		Content: "a",
+		Files:   []models.File{{Filename: "b.txt", Content: "b"}, {Filename: "c.txt", Content: "c"}},
+	}
+
+	files := diffFiles(from, models.Revision{
+		Content: "a",
+		Files:   []models.File{{Filename: "d.txt", Content: "b"}},
+	})
+	assert.Equal(t, len(files), 2)
+	assert.Equal(t, files[0].Name, "d.txt")
+	assert.Equal(t, files[0].Note, "Renamed from b.txt")
+	assert.Equal(t, len(files[0].Hunks), 0)
+	assert.Equal(t, files[1].Name, "c.txt")
+	assert.Equal(t, files[1].Note, "Removed")
+
+	files = diffFiles(models.Revision{Content: "a"}, models.Revision{Content: "b"})
+	assert.Equal(t, len(files), 1)
+	assert.Equal(t, files[0].Name, "")
+	assert.Equal(t, len(files[0].Hunks), 1)
+
+	assert.Equal(t, len(diffFiles(from, from)), 0)
+
+	// Files too large to compare are only marked as changed.
+	large := models.Revision{Content: strings.Repeat("a\n", maxDiffLines)}
+	files = diffFiles(large, models.Revision{Content: large.Content + "b\n"})
+	assert.Equal(t, len(files), 1)
+	assert.Equal(t, files[0].TooLarge, true)
+	assert.Equal(t, len(files[0].Hunks), 0)
+	assert.Equal(t, len(diffFiles(large, large)), 0)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// runPeriodically calls fn every interval in a background goroutine until the
+// application exits. Errors and panics are logged rather than stopping the job,
+// so a transient failure such as a lost database connection is retried on the next tick.
+func (app *application) runPeriodically(name string, interval time.Duration, fn func() error) {
+	run := func() {
+		// Recover from any panic, so it doesn't bring the whole server down.
+		defer func() {
+			if err := recover(); err != nil {
+				app.logger.Error(fmt.Sprintf("%s", err), "job", name)
+			}
+		}()
+
+		err := fn()
+		if err != nil {
+			app.logger.Error(err.Error(), "job", name)
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			run()
+		}
+	}()
+}
+
+// purgeDeletedSnippets starts a background job which permanently removes the snippets
+// that were deleted longer ago than the retention period.
+func (app *application) purgeDeletedSnippets(interval, retention time.Duration) {
+	app.runPeriodically("purge deleted snippets", interval, func() error {
+		n, err := app.snippets.Purge(retention)
+		if err != nil {
+			return err
+		}
+
+		if n > 0 {
+			app.logger.Info("purged deleted snippets", "count", n)
+		}
+
+		return nil
+	})
+}
+
+// deleteExpiredSnippets starts a background job which permanently removes the snippets
+// that have expired. Expired snippets are already hidden, so this only reclaims space.
+func (app *application) deleteExpiredSnippets(interval time.Duration) {
+	app.runPeriodically("delete expired snippets", interval, func() error {
+		n, err := app.snippets.DeleteExpired()
+		if err != nil {
+			return err
+		}
+
+		if n > 0 {
+			app.logger.Info("deleted expired snippets", "count", n)
+		}
+
+		return nil
+	})
+}
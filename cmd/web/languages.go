@@ -1,69 +1,40 @@
 package main
 
-type Language struct {
-	Key   string
-	Value string
-}
+import "ssnipp.com/internal/languages"
 
-// Language represents a programming language with a key and a value.
-func getLanguages() []Language {
-	pairs := []Language{
-		{Key: "plaintext", Value: "Plain Text"},
-		{Key: "html", Value: "HTML"},
-		{Key: "css", Value: "CSS"},
-		{Key: "scss", Value: "SCSS"},
-		{Key: "javascript", Value: "JavaScript"},
-		{Key: "typescript", Value: "TypeScript"},
-		{Key: "json", Value: "JSON"},
-		{Key: "php", Value: "PHP"},
-		{Key: "python", Value: "Python"},
-		{Key: "go", Value: "GO"},
-		{Key: "sql", Value: "SQL"},
-		{Key: "bash", Value: "Bash"},
-		{Key: "xml", Value: "XML"},
-		{Key: "c", Value: "C"},
-		{Key: "cpp", Value: "C++"},
-		{Key: "csharp", Value: "C#"},
-		{Key: "java", Value: "Java"},
-		{Key: "swift", Value: "Swift"},
-		{Key: "rust", Value: "Rust"},
-		{Key: "ruby", Value: "Ruby"},
-		{Key: "perl", Value: "Perl"},
-		{Key: "lua", Value: "Lua"},
-		{Key: "shell", Value: "Shell"},
-	}
-	return pairs
+// getLanguages returns the supported languages, in the order they are offered to users.
+func getLanguages() []languages.Language {
+	return languages.Default.All()
 }
 
-// getLanguages returns a slice of Language structs containing supported languages.
-// Each Language struct contains a key (used internally) and a value (display name).
-func getLanguageKeys() []string {
-	// Retrieve the list of languages.
-	languages := getLanguages()
+// getCreateLanguages returns the languages offered for new snippets, starting with the
+// option to detect the language from the content.
+func getCreateLanguages() []languages.Language {
+	return append([]languages.Language{{Key: autoLanguage, Label: "Auto-detect"}}, getLanguages()...)
+}
 
-	// Initialize a slice to hold the keys.
-	keys := make([]string, len(languages))
+// isLanguage reports whether the key matches a supported language.
+func isLanguage(key string) bool {
+	return languages.Default.Has(key)
+}
 
-	// Populate the slice with the keys of the languages.
-	for i, lang := range languages {
-		keys[i] = lang.Key
+// resolveLanguage returns the key of the language with the given key or alias, such as
+// "javascript" for "js". Unknown names are returned unchanged, for validation to reject.
+func resolveLanguage(name string) string {
+	if language, ok := languages.Default.Lookup(name); ok {
+		return language.Key
 	}
-	return keys
+	return name
 }
 
 // getLanguageLabel returns the display name of a language given its key.
 // If the key does not match any language, it returns "Plain Text" as the default value.
-func getLanguageLabel(s string) string {
-	// Retrieve the list of languages.
-	languages := getLanguages()
-
-	// Iterate through the languages to find a match for the key.
-	for _, pair := range languages {
-		if pair.Key == s {
-			return pair.Value
-		}
-	}
+func getLanguageLabel(key string) string {
+	return languages.Default.Label(key)
+}
 
-	// If no match is found, return "Plain Text" as the default value.
-	return "Plain Text"
+// getLanguageHighlight returns the class used to highlight a language given its key, as
+// in "language-bash" for shell scripts.
+func getLanguageHighlight(key string) string {
+	return languages.Default.Highlight(key)
 }
@@ -7,8 +7,10 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"ssnipp.com/internal/highlight"
 	"ssnipp.com/internal/models"
 
 	"github.com/alexedwards/scs/mysqlstore"
@@ -23,10 +25,15 @@ type application struct {
 	logger         *slog.Logger
 	snippets       models.SnippetModelInterface
 	users          models.UserModelInterface
+	tokens         models.TokenModelInterface
+	collections    models.CollectionModelInterface
 	templateCache  map[string]*template.Template
+	highlighter    *highlight.Cache
 	formDecoder    *form.Decoder
 	sessionManager *scs.SessionManager
 	allowSignup    bool
+	exampleSnippet string
+	embedAncestors string
 }
 
 // The main() function, which is the entry point for the application.
@@ -112,6 +119,41 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Read the EXAMPLE_SNIPPET environment variable to get the slug of a snippet
+	// which is linked from the login page as an example. If the environment variable
+	// isn't set, no example is linked.
+	exampleSnippet := os.Getenv("EXAMPLE_SNIPPET")
+
+	// Read the EMBED_ANCESTORS environment variable to get the space-separated list of
+	// sources allowed to embed snippets, as used by the frame-ancestors directive of the
+	// Content-Security-Policy, such as "https://wiki.example.com". If the environment
+	// variable isn't set, we default to "*", which allows any site.
+	embedAncestors := os.Getenv("EMBED_ANCESTORS")
+	if embedAncestors == "" {
+		embedAncestors = "*"
+	}
+
+	// Reject values which would end the directive and add others to the policy...
+	if strings.ContainsAny(embedAncestors, ";,\r\n") {
+		logger.Error("Error parsing EMBED_ANCESTORS environment variable")
+		os.Exit(1)
+	}
+
+	// Read the DELETED_RETENTION environment variable to determine how long deleted
+	// snippets are kept before being purged. If the environment variable isn't set,
+	// we default to 30 days.
+	deletedRetentionStr := os.Getenv("DELETED_RETENTION")
+	if deletedRetentionStr == "" {
+		deletedRetentionStr = "720h"
+	}
+
+	// Parse the DELETED_RETENTION environment variable to a duration...
+	deletedRetention, err := time.ParseDuration(deletedRetentionStr)
+	if err != nil {
+		logger.Error("Error parsing DELETED_RETENTION environment variable")
+		os.Exit(1)
+	}
+
 	// Initialize a new template cache...
 	templateCache, err := newTemplateCache()
 	if err != nil {
@@ -133,12 +175,21 @@ func main() {
 		logger:         logger,
 		snippets:       &models.SnippetModel{DB: db},
 		users:          &models.UserModel{DB: db},
+		tokens:         &models.TokenModel{DB: db},
+		collections:    &models.CollectionModel{DB: db},
 		templateCache:  templateCache,
+		highlighter:    highlight.NewCache(highlightCacheSize),
 		formDecoder:    formDecoder,
 		sessionManager: sessionManager,
 		allowSignup:    allowSignup,
+		exampleSnippet: exampleSnippet,
+		embedAncestors: embedAncestors,
 	}
 
+	// Start the background jobs which purge deleted snippets and remove expired ones...
+	app.purgeDeletedSnippets(time.Hour, deletedRetention)
+	app.deleteExpiredSnippets(time.Minute)
+
 	// Initialize a new HTTP server...
 	srv := &http.Server{
 		Addr:         addr,
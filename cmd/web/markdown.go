@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	"ssnipp.com/internal/highlight"
+	"ssnipp.com/internal/languages"
+)
+
+// markdownRenderer converts the Markdown written by users to HTML. Raw HTML is left out
+// and links with dangerous URLs, such as javascript: ones, are dropped, so the output is
+// safe to include in pages as it is. Fenced code blocks are highlighted like snippets,
+// with classes only, so the output is allowed by the Content-Security-Policy.
+var markdownRenderer = goldmark.New(
+	goldmark.WithParserOptions(
+		parser.WithASTTransformers(util.Prioritized(linkRelTransformer{}, 100)),
+	),
+	goldmark.WithRendererOptions(
+		renderer.WithNodeRenderers(util.Prioritized(codeBlockRenderer{}, 100)),
+	),
+)
+
+// linkRelTransformer marks the links written by users as untrusted, so that search
+// engines don't credit them to the site, and the linked pages can't reach back to it.
+type linkRelTransformer struct{}
+
+// Transform adds a rel attribute to every link and autolink of the document.
+func (linkRelTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering && (n.Kind() == ast.KindLink || n.Kind() == ast.KindAutoLink) {
+			n.SetAttributeString("rel", []byte("nofollow ugc noopener"))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// codeBlockRenderer renders fenced code blocks highlighted in the language named after
+// their opening fence, which can be any key or alias of the supported languages.
+type codeBlockRenderer struct{}
+
+// RegisterFuncs registers the renderer of fenced code blocks, taking over from the
+// default one.
+func (codeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, renderCodeBlock)
+}
+
+// renderCodeBlock writes a fenced code block highlighted, in the markup of the default
+// renderer.
+func renderCodeBlock(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	block := n.(*ast.FencedCodeBlock)
+
+	var code strings.Builder
+	lines := block.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		code.Write(segment.Value(source))
+	}
+
+	// Only the classes of known languages are written, so the language named by users
+	// never ends up in the markup
+	class := "plaintext"
+	if language, ok := languages.Default.Lookup(string(block.Language(source))); ok {
+		class = language.Highlight
+	}
+
+	_, err := fmt.Fprintf(w, "<pre><code class=\"hljs language-%s\">%s</code></pre>\n", class, highlight.HTML(code.String(), class))
+	return ast.WalkSkipChildren, err
+}
+
+// markdown renders Markdown text as HTML for templates. Errors can only come from the
+// buffer, so the text is shown escaped if rendering fails.
+func markdown(source string) template.HTML {
+	var buf bytes.Buffer
+
+	err := markdownRenderer.Convert([]byte(source), &buf)
+	if err != nil {
+		return template.HTML(template.HTMLEscapeString(source))
+	}
+
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"ssnipp.com/internal/assert"
+)
+
+// TestMarkdown tests that the markdown function renders Markdown without letting users
+// inject HTML or scripts into pages.
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string // Name of the test case.
+		source string // Markdown to render.
+		want   string // Expected HTML.
+	}{
+		{
+			name:   "Emphasis",
+			source: "Runs **every** service.",
+			want:   "<p>Runs <strong>every</strong> service.</p>\n",
+		},
+		{
+			name:   "Link",
+			source: "[docs](https://example.com)",
+			want:   "<p><a href=\"https://example.com\" rel=\"nofollow ugc noopener\">docs</a></p>\n",
+		},
+		{
+			name:   "Autolink",
+			source: "<https://example.com>",
+			want:   "<p><a href=\"https://example.com\" rel=\"nofollow ugc noopener\">https://example.com</a></p>\n",
+		},
+		{
+			name:   "Raw HTML",
+			source: "<script>alert(1)</script>",
+			want:   "<!-- raw HTML omitted -->\n",
+		},
+		{
+			name:   "JavaScript link",
+			source: "[click](javascript:alert(1))",
+			want:   "<p><a href=\"\" rel=\"nofollow ugc noopener\">click</a></p>\n",
+		},
+		{
+			name:   "Fenced code",
+			source: "```js\nlet a = \"<b>\";\n```",
+			want:   "<pre><code class=\"hljs language-javascript\"><span class=\"hljs-keyword\">let</span> a = <span class=\"hljs-string\">&#34;&lt;b&gt;&#34;</span>;\n</code></pre>\n",
+		},
+		{
+			name:   "Fenced code in an unknown language",
+			source: "```\"><script>\n<b>\n```",
+			want:   "<pre><code class=\"hljs language-plaintext\">&lt;b&gt;\n</code></pre>\n",
+		},
+		{
+			name:   "Escaped text",
+			source: "a < b && c > d",
+			want:   "<p>a &lt; b &amp;&amp; c &gt; d</p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, string(markdown(tt.source)), tt.want)
+		})
+	}
+}
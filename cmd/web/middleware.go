@@ -2,18 +2,24 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/justinas/nosurf"
+	"ssnipp.com/internal/models"
 )
 
+// contentSecurityPolicy is the Content-Security-Policy of every response. Only the
+// resources of the site itself can be loaded, so scripts and styles can't be inlined.
+const contentSecurityPolicy = "default-src 'self'; style-src 'self'; font-src 'self'; img-src 'self' data:;"
+
 // commonHeaders middleware sets various security-related HTTP headers on the response.
 func commonHeaders(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Set Content Security Policy (CSP) header.
-		w.Header().Set("Content-Security-Policy",
-			"default-src 'self'; style-src 'self'; font-src 'self'; img-src 'self' data:;")
+		w.Header().Set("Content-Security-Policy", contentSecurityPolicy)
 
 		// Set Referrer Policy header.
 		w.Header().Set("Referrer-Policy", "origin-when-cross-origin")
@@ -29,6 +35,18 @@ func commonHeaders(next http.Handler) http.Handler {
 	})
 }
 
+// allowFraming middleware relaxes the framing policy set by commonHeaders, so that the
+// pages of a route can be embedded in iframes by the sites listed in embedAncestors
+// instead of being denied to every site.
+func (app *application) allowFraming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Del("X-Frame-Options")
+		w.Header().Set("Content-Security-Policy", contentSecurityPolicy+" frame-ancestors "+app.embedAncestors+";")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // logRequest middleware logs details of each incoming HTTP request.
 func (app *application) logRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -86,6 +104,23 @@ func (app *application) requireAuthentication(next http.Handler) http.Handler {
 	})
 }
 
+// requireAPIAuthentication middleware checks if a user is authenticated, otherwise sends a
+// 401 Unauthorized JSON response, as API clients can't follow the redirect to the login page.
+func (app *application) requireAPIAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.isAuthenticated(r) {
+			app.errorJSON(w, r, http.StatusUnauthorized, "you must be authenticated to access this resource")
+			return
+		}
+
+		// Set Cache-Control header to prevent caching of authenticated responses.
+		w.Header().Add("Cache-Control", "no-store")
+
+		// Call the next handler in the chain.
+		next.ServeHTTP(w, r)
+	})
+}
+
 // noSurf middleware sets up CSRF protection using the nosurf package.
 func noSurf(next http.Handler) http.Handler {
 	csrfHandler := nosurf.New(next)
@@ -120,6 +155,7 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 		// If a matching user is found, add authentication information to the request context.
 		if exists {
 			ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
+			ctx = context.WithValue(ctx, authenticatedUserIDContextKey, id)
 			r = r.WithContext(ctx)
 		}
 
@@ -127,3 +163,67 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// authenticateToken middleware authenticates API requests made with a personal API token in
+// an "Authorization: Bearer <token>" header, and adds the same authentication information to
+// the request context as the authenticate middleware, along with the scope of the token.
+// Requests with an invalid token are rejected with a 401 Unauthorized JSON response.
+func (app *application) authenticateToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The response depends on the Authorization header, so caches must take it into account.
+		w.Header().Add("Vary", "Authorization")
+
+		authorizationHeader := r.Header.Get("Authorization")
+		if authorizationHeader == "" {
+			// If no token is provided, call the next handler in the chain.
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		invalidToken := func() {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			app.errorJSON(w, r, http.StatusUnauthorized, "invalid or missing authentication token")
+		}
+
+		scheme, plaintext, ok := strings.Cut(authorizationHeader, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || plaintext == "" {
+			invalidToken()
+			return
+		}
+
+		// Look up the token by its hash.
+		token, err := app.tokens.Authenticate(plaintext)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCredentials) {
+				invalidToken()
+			} else {
+				app.serverErrorJSON(w, r, err)
+			}
+			return
+		}
+
+		// Add authentication information to the request context, replacing any from the session.
+		ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
+		ctx = context.WithValue(ctx, authenticatedUserIDContextKey, token.UserID)
+		ctx = context.WithValue(ctx, tokenScopeContextKey, token.Scope)
+		r = r.WithContext(ctx)
+
+		// Call the next handler in the chain.
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireWriteScope middleware rejects requests authenticated with a read-only personal API
+// token with a 403 Forbidden JSON response. Requests authenticated with a session are allowed.
+func (app *application) requireWriteScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope, ok := r.Context().Value(tokenScopeContextKey).(string)
+		if ok && scope != models.ScopeWrite {
+			app.errorJSON(w, r, http.StatusForbidden, "this token is not allowed to modify snippets")
+			return
+		}
+
+		// Call the next handler in the chain.
+		next.ServeHTTP(w, r)
+	})
+}
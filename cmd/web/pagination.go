@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// snippetsPerPage is the number of snippets displayed on each page of a listing.
+const snippetsPerPage = 20
+
+// pagination holds the state needed to render the previous/next links of a
+// paginated listing. Query holds any other query string parameters to keep in
+// the links, such as a search query.
+type pagination struct {
+	Page         int
+	PageSize     int
+	TotalRecords int
+	Query        url.Values
+}
+
+// newPagination returns a pagination for the given page number, page size and
+// total number of records.
+func newPagination(page, pageSize, totalRecords int) pagination {
+	return pagination{
+		Page:         page,
+		PageSize:     pageSize,
+		TotalRecords: totalRecords,
+	}
+}
+
+// Offset returns the number of records to skip to reach the current page.
+func (p pagination) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// TotalPages returns the number of pages needed to display all the records.
+func (p pagination) TotalPages() int {
+	if p.PageSize < 1 {
+		return 0
+	}
+
+	return (p.TotalRecords + p.PageSize - 1) / p.PageSize
+}
+
+// HasPrevious reports whether there is a page before the current one.
+func (p pagination) HasPrevious() bool {
+	return p.Page > 1
+}
+
+// HasNext reports whether there is a page after the current one.
+func (p pagination) HasNext() bool {
+	return p.Page < p.TotalPages()
+}
+
+// Previous returns the number of the previous page.
+func (p pagination) Previous() int {
+	return p.Page - 1
+}
+
+// Next returns the number of the next page.
+func (p pagination) Next() int {
+	return p.Page + 1
+}
+
+// PageURL returns the relative URL of the given page, keeping the other query string
+// parameters of the listing, such as a search query.
+func (p pagination) PageURL(page int) string {
+	query := url.Values{}
+	for key, values := range p.Query {
+		query[key] = values
+	}
+
+	query.Set("page", strconv.Itoa(page))
+
+	return "?" + query.Encode()
+}
@@ -25,8 +25,23 @@ func (app *application) routes() http.Handler {
 	// CSRF protection, and authentication middleware.
 	dynamic := alice.New(app.sessionManager.LoadAndSave, noSurf, app.authenticate)
 
-	// Add routes for viewing snippets and user login.
-	mux.Handle("GET /view/{id}", dynamic.ThenFunc(app.snippetView))
+	// Add routes for exploring, searching and browsing snippets by tag or collection,
+	// viewing and unlocking snippets, their raw content, history and diffs, embedding
+	// snippets in other sites, and user login. Embeds can be framed by other sites.
+	mux.Handle("GET /explore", dynamic.ThenFunc(app.snippetExplore))
+	mux.Handle("GET /search", dynamic.ThenFunc(app.snippetSearch))
+	mux.Handle("GET /tags/{tag}", dynamic.ThenFunc(app.snippetTag))
+	mux.Handle("GET /collections/{slug}", dynamic.ThenFunc(app.collectionView))
+	mux.Handle("GET /view/{slug}", dynamic.ThenFunc(app.snippetView))
+	mux.Handle("POST /view/{slug}/unlock", dynamic.ThenFunc(app.snippetUnlockPost))
+	mux.Handle("GET /raw/{slug}", dynamic.ThenFunc(app.snippetRaw))
+	mux.Handle("GET /raw/{slug}/{file}", dynamic.ThenFunc(app.snippetRaw))
+	mux.Handle("GET /download/{slug}", dynamic.ThenFunc(app.snippetDownload))
+	mux.Handle("GET /download/{slug}/{file}", dynamic.ThenFunc(app.snippetDownload))
+	mux.Handle("GET /view/{slug}/history", dynamic.ThenFunc(app.snippetHistory))
+	mux.Handle("GET /view/{slug}/rev/{n}", dynamic.ThenFunc(app.snippetRevisionView))
+	mux.Handle("GET /view/{slug}/diff", dynamic.ThenFunc(app.snippetDiff))
+	mux.Handle("GET /embed/{slug}", dynamic.Append(app.allowFraming).ThenFunc(app.snippetEmbed))
 	mux.Handle("GET /login", dynamic.ThenFunc(app.userLogin))
 	mux.Handle("POST /login", dynamic.ThenFunc(app.userLoginPost))
 
@@ -41,11 +56,39 @@ func (app *application) routes() http.Handler {
 	// which includes the requireAuthentication middleware.
 	protected := dynamic.Append(app.requireAuthentication)
 
-	// Add routes for home, snippet creation, and user logout.
+	// Add routes for home, snippet creation, listing, forking, editing and deletion,
+	// collection and API token management, and user logout.
 	mux.Handle("GET /{$}", protected.ThenFunc(app.home))
+	mux.Handle("GET /snippets", protected.ThenFunc(app.snippetList))
 	mux.Handle("POST /create", protected.ThenFunc(app.snippetCreatePost))
+	mux.Handle("GET /fork/{slug}", protected.ThenFunc(app.snippetFork))
+	mux.Handle("GET /edit/{slug}", protected.ThenFunc(app.snippetEdit))
+	mux.Handle("POST /edit/{slug}", protected.ThenFunc(app.snippetEditPost))
+	mux.Handle("POST /delete/{slug}", protected.ThenFunc(app.snippetDeletePost))
+	mux.Handle("GET /collections", protected.ThenFunc(app.collectionList))
+	mux.Handle("POST /collections", protected.ThenFunc(app.collectionCreatePost))
+	mux.Handle("POST /collections/{slug}/delete", protected.ThenFunc(app.collectionDeletePost))
+	mux.Handle("POST /collections/add", protected.ThenFunc(app.collectionAddPost))
+	mux.Handle("POST /collections/{slug}/snippets/{snippet}/remove", protected.ThenFunc(app.collectionRemovePost))
+	mux.Handle("GET /account/tokens", protected.ThenFunc(app.tokenList))
+	mux.Handle("POST /account/tokens", protected.ThenFunc(app.tokenCreatePost))
+	mux.Handle("POST /account/tokens/{id}/revoke", protected.ThenFunc(app.tokenRevokePost))
 	mux.Handle("POST /logout", protected.ThenFunc(app.userLogoutPost))
 
+	// Create a middleware chain for the JSON API, which includes the session manager and
+	// authentication middleware, for both sessions and personal API tokens, but no CSRF
+	// protection, as API clients don't submit HTML forms. Cross-site requests are still
+	// rejected, as the API only accepts JSON bodies.
+	api := alice.New(app.sessionManager.LoadAndSave, app.authenticate, app.authenticateToken)
+	apiProtected := api.Append(app.requireAPIAuthentication)
+	apiWrite := apiProtected.Append(app.requireWriteScope)
+
+	// Add routes for version 1 of the API.
+	mux.Handle("GET /api/v1/snippets/{slug}", api.ThenFunc(app.apiSnippetView))
+	mux.Handle("GET /api/v1/snippets", apiProtected.ThenFunc(app.apiSnippetList))
+	mux.Handle("POST /api/v1/snippets", apiWrite.ThenFunc(app.apiSnippetCreate))
+	mux.Handle("DELETE /api/v1/snippets/{slug}", apiWrite.ThenFunc(app.apiSnippetDelete))
+
 	// Create a standard middleware chain which includes the panic recovery,
 	// request logging, and common security headers middleware.
 	standard := alice.New(app.recoverPanic, app.logRequest, commonHeaders)
@@ -0,0 +1,45 @@
+package main
+
+import "ssnipp.com/internal/models"
+
+// Scope represents a scope option for personal API tokens, with a key and a value.
+type Scope struct {
+	Key   string
+	Value string
+}
+
+// getScopes returns a slice of Scope structs containing the scope options.
+// Each Scope struct contains a key (stored with the token) and a value (display name).
+func getScopes() []Scope {
+	pairs := []Scope{
+		{Key: models.ScopeRead, Value: "Read only"},
+		{Key: models.ScopeWrite, Value: "Read and write"},
+	}
+	return pairs
+}
+
+// getScopeKeys returns the keys of the scope options.
+func getScopeKeys() []string {
+	// Retrieve the list of scopes.
+	scopes := getScopes()
+
+	// Initialize a slice to hold the keys.
+	keys := make([]string, len(scopes))
+
+	// Populate the slice with the keys of the scopes.
+	for i, s := range scopes {
+		keys[i] = s.Key
+	}
+	return keys
+}
+
+// getScopeLabel returns the display name of a scope given its key.
+// If the key does not match any scope, it returns the key itself.
+func getScopeLabel(key string) string {
+	for _, s := range getScopes() {
+		if s.Key == key {
+			return s.Value
+		}
+	}
+	return key
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"ssnipp.com/internal/models"
+)
+
+// maxSearchLines is the maximum number of matching lines shown for each search result.
+const maxSearchLines = 5
+
+// searchResult is a snippet found by a search, along with its lines matching the query.
+type searchResult struct {
+	Snippet models.Snippet
+	Lines   []searchLine
+}
+
+// searchLine is a line of a snippet matching a search query, split into parts so that
+// the matching words can be highlighted. File is the name of the file of the line, for
+// snippets made of several files.
+type searchLine struct {
+	File   string
+	Number int
+	Parts  []searchPart
+}
+
+// searchPart is a part of a searchLine, which either matches the query or not.
+type searchPart struct {
+	Text  string
+	Match bool
+}
+
+// searchTerms splits a search query into words, the same way as the snippet model does
+// for the FULLTEXT search.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+// snippetMatchingLines returns the first lines of the files of the snippet containing any
+// of the words of the query, like matchingLines, in the order of AllFiles.
+func snippetMatchingLines(snippet models.Snippet, query string) []searchLine {
+	files := snippet.AllFiles()
+	lines := []searchLine{}
+
+	for i, f := range files {
+		for _, l := range matchingLines(f.Content, query) {
+			if len(lines) == maxSearchLines {
+				return lines
+			}
+
+			if len(files) > 1 {
+				l.File = cmp.Or(f.Filename, fmt.Sprintf("File %d", i+1))
+			}
+			lines = append(lines, l)
+		}
+	}
+
+	return lines
+}
+
+// matchingLines returns the first lines of the content containing any of the words of
+// the query, ignoring case, with the matching words marked.
+func matchingLines(content, query string) []searchLine {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	for i, term := range terms {
+		terms[i] = regexp.QuoteMeta(term)
+	}
+	rx := regexp.MustCompile("(?i)" + strings.Join(terms, "|"))
+
+	lines := []searchLine{}
+
+	for i, line := range strings.Split(content, "\n") {
+		matches := rx.FindAllStringIndex(line, -1)
+		if matches == nil {
+			continue
+		}
+
+		l := searchLine{Number: i + 1}
+		start := 0
+
+		for _, m := range matches {
+			if m[0] > start {
+				l.Parts = append(l.Parts, searchPart{Text: line[start:m[0]]})
+			}
+			l.Parts = append(l.Parts, searchPart{Text: line[m[0]:m[1]], Match: true})
+			start = m[1]
+		}
+
+		if start < len(line) {
+			l.Parts = append(l.Parts, searchPart{Text: line[start:]})
+		}
+
+		lines = append(lines, l)
+		if len(lines) == maxSearchLines {
+			break
+		}
+	}
+
+	return lines
+}
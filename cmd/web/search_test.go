@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"ssnipp.com/internal/assert"
+	"ssnipp.com/internal/models"
+)
+
+// TestSnippetMatchingLines tests that the lines matching a search query are found in
+// every file of a snippet, named when there are several.
+func TestSnippetMatchingLines(t *testing.T) {
+	snippet := models.Snippet{
+		Content: "main",
+		Files:   []models.File{{Filename: "a.go", Content: "x\nmain"}, {Content: "main\nmain\nmain\nmain"}},
+	}
+
+	lines := snippetMatchingLines(snippet, "main")
+	assert.Equal(t, len(lines), maxSearchLines)
+	assert.Equal(t, lines[0].File, "File 1")
+	assert.Equal(t, lines[1].File, "a.go")
+	assert.Equal(t, lines[1].Number, 2)
+	assert.Equal(t, lines[2].File, "File 3")
+
+	lines = snippetMatchingLines(models.Snippet{Content: "main"}, "main")
+	assert.Equal(t, len(lines), 1)
+	assert.Equal(t, lines[0].File, "")
+}
+
+// TestMatchingLines tests that the lines matching a search query are found, with the
+// matching words marked.
+func TestMatchingLines(t *testing.T) {
+	content := "package main\n\nfunc main() {\n\tfmt.Println(\"Hello, World!\")\n}"
+
+	t.Run("Matching words", func(t *testing.T) {
+		lines := matchingLines(content, "MAIN")
+
+		assert.Equal(t, len(lines), 2)
+		assert.Equal(t, lines[0].Number, 1)
+		assert.Equal(t, len(lines[0].Parts), 2)
+		assert.Equal(t, lines[0].Parts[0], searchPart{Text: "package "})
+		assert.Equal(t, lines[0].Parts[1], searchPart{Text: "main", Match: true})
+
+		assert.Equal(t, lines[1].Number, 3)
+		assert.Equal(t, len(lines[1].Parts), 3)
+		assert.Equal(t, lines[1].Parts[2], searchPart{Text: "() {"})
+	})
+
+	t.Run("Several words", func(t *testing.T) {
+		lines := matchingLines(content, "hello world")
+
+		assert.Equal(t, len(lines), 1)
+		assert.Equal(t, lines[0].Number, 4)
+		assert.Equal(t, lines[0].Parts[1], searchPart{Text: "Hello", Match: true})
+		assert.Equal(t, lines[0].Parts[3], searchPart{Text: "World", Match: true})
+	})
+
+	t.Run("Special characters", func(t *testing.T) {
+		assert.Equal(t, len(matchingLines(content, "fmt.*")), 1)
+	})
+
+	t.Run("No words", func(t *testing.T) {
+		assert.Equal(t, len(matchingLines(content, "()")), 0)
+	})
+
+	t.Run("Maximum number of lines", func(t *testing.T) {
+		assert.Equal(t, len(matchingLines("a\na\na\na\na\na\na", "a")), maxSearchLines)
+	})
+}
@@ -1,28 +1,74 @@
 package main
 
 import (
+	"fmt"
 	"html/template"
 	"io/fs"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"ssnipp.com/internal/diff"
+	"ssnipp.com/internal/languages"
 	"ssnipp.com/internal/models"
 	"ssnipp.com/ui"
 )
 
 // templateData type acts as the holding structure for any dynamic data that
 // we want to pass to our HTML templates. It contains fields for the current year,
-// snippet data, form data, flash messages, authentication status, CSRF token,
-// signup allowance, and available languages.
+// snippet data, the highlighted and rendered files of snippets, form data, flash
+// messages, authentication status, CSRF token, signup allowance, available languages,
+// expirations and visibilities, paginated snippet listings, snippet revisions, personal
+// API tokens, search results, tags, collections, the snippet a snippet was forked
+// from, and the file shown in embed pages.
 type templateData struct {
-	CurrentYear     int
-	Snippet         models.Snippet
-	Form            any
-	Flash           string
-	IsAuthenticated bool
-	CSRFToken       string
-	AllowSignup     bool
-	Languages       []Language
+	CurrentYear         int
+	Snippet             models.Snippet
+	Snippets            []models.Snippet
+	Pagination          pagination
+	Revision            models.Revision
+	Highlighted         []template.HTML
+	Rendered            []template.HTML
+	Embed               embedData
+	Revisions           []models.Revision
+	Diff                revisionDiff
+	Form                any
+	Flash               string
+	IsAuthenticated     bool
+	AuthenticatedUserID int
+	CSRFToken           string
+	AllowSignup         bool
+	ExampleSnippet      string
+	Languages           []languages.Language
+	Expirations         []Expiration
+	Visibilities        []Visibility
+	Tokens              []models.Token
+	NewToken            string
+	Scopes              []Scope
+	SearchResults       []searchResult
+	Tag                 string
+	Collection          models.Collection
+	Collections         []models.Collection
+	ForkedFrom          models.Snippet
+}
+
+// revisionDiff holds the differences between two revisions of a snippet, for each of
+// the files which changed.
+type revisionDiff struct {
+	From  models.Revision
+	To    models.Revision
+	Files []fileDiff
+}
+
+// fileDiff holds the differences in a file between two revisions, grouped into hunks
+// for display. The Name is empty for snippets made of a single unnamed file, and the
+// Note tells whether the file was added, removed or renamed. TooLarge is set instead of
+// the hunks for files which changed but are too large to compare.
+type fileDiff struct {
+	Name     string
+	Note     string
+	Hunks    []diff.Hunk
+	TooLarge bool
 }
 
 // newTemplateCache creates a template cache as a map. The map's keys are the names of the templates
@@ -59,6 +105,14 @@ func newTemplateCache() (map[string]*template.Template, error) {
 		cache[name] = ts
 	}
 
+	// Embed pages are framed by other sites, so they have their own minimal layout
+	// instead of the base one.
+	ts, err := template.New("embed.html").Funcs(functions).ParseFS(ui.Files, "html/embed.html")
+	if err != nil {
+		return nil, err
+	}
+	cache["embed.html"] = ts
+
 	return cache, nil
 }
 
@@ -73,10 +127,105 @@ func humanDate(t time.Time) string {
 	return t.UTC().Format("02 Jan 2006 at 15:04")
 }
 
+// previewLines and previewChars limit the size of the snippet previews shown in listings.
+const (
+	previewLines = 5
+	previewChars = 300
+)
+
+// snippetPreview returns the first few lines of a snippet's content, to give an idea
+// of the snippet in listings. An ellipsis is appended if the content was truncated.
+func snippetPreview(content string) string {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+
+	truncated := false
+	if len(lines) > previewLines {
+		lines = lines[:previewLines]
+		truncated = true
+	}
+
+	preview := strings.Join(lines, "\n")
+
+	// Truncate on rune boundaries so multi-byte characters aren't split.
+	if runes := []rune(preview); len(runes) > previewChars {
+		preview = string(runes[:previewChars])
+		truncated = true
+	}
+
+	if truncated {
+		preview += "\n…"
+	}
+
+	return preview
+}
+
+// snippetTitle returns the title of a snippet, or a title made from its ID for snippets
+// which weren't given one.
+func snippetTitle(snippet models.Snippet) string {
+	if snippet.Title != "" {
+		return snippet.Title
+	}
+
+	return fmt.Sprintf("Snippet #%d", snippet.ID)
+}
+
+// add returns the sum of two integers, such as to number items from 1 in templates.
+func add(a, b int) int {
+	return a + b
+}
+
+// splitLines splits highlighted code into its lines, to number them. Highlighted code
+// never has markup spanning several lines, so every line is valid HTML on its own. A
+// final line break doesn't start another line.
+func splitLines(code template.HTML) []template.HTML {
+	s := strings.TrimSuffix(string(code), "\n")
+
+	var lines []template.HTML
+	for _, line := range strings.Split(s, "\n") {
+		lines = append(lines, template.HTML(line))
+	}
+	return lines
+}
+
+// diffLineClass returns the CSS classes used to color a line of a diff.
+func diffLineClass(op diff.Op) string {
+	switch op {
+	case diff.Insert:
+		return "bg-green-100"
+	case diff.Delete:
+		return "bg-red-100"
+	default:
+		return ""
+	}
+}
+
+// diffLinePrefix returns the marker shown before a line of a diff, as in unified diffs.
+func diffLinePrefix(op diff.Op) string {
+	switch op {
+	case diff.Insert:
+		return "+"
+	case diff.Delete:
+		return "-"
+	default:
+		return " "
+	}
+}
+
 // Initialize a template.FuncMap object and store it in a global variable. This is essentially
 // a string-keyed map which acts as a lookup between the names of our custom template functions
 // and the functions themselves.
 var functions = template.FuncMap{
-	"humanDate":        humanDate,
-	"getLanguageLabel": getLanguageLabel,
+	"humanDate":            humanDate,
+	"getLanguageLabel":     getLanguageLabel,
+	"getLanguageHighlight": getLanguageHighlight,
+	"getVisibilityLabel":   getVisibilityLabel,
+	"getScopeLabel":        getScopeLabel,
+	"snippetPreview":       snippetPreview,
+	"snippetTitle":         snippetTitle,
+	"markdown":             markdown,
+	"join":                 strings.Join,
+	"add":                  add,
+	"splitLines":           splitLines,
+	"diffLineClass":        diffLineClass,
+	"diffLinePrefix":       diffLinePrefix,
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"html/template"
+	"strings"
 	"testing"
 	"time"
 
@@ -93,3 +95,55 @@ func TestGetLanguageLabel(t *testing.T) {
 		})
 	}
 }
+
+// TestSnippetPreview tests the snippetPreview function to ensure it truncates long content.
+func TestSnippetPreview(t *testing.T) {
+	tests := []struct {
+		name    string // Name of the test case.
+		content string // Input content to test.
+		want    string // Expected output string.
+	}{
+		{
+			name:    "Short content",
+			content: "console.log();",
+			want:    "console.log();",
+		},
+		{
+			name:    "Surrounding whitespace",
+			content: "\n\n  a := 1\n\n",
+			want:    "a := 1",
+		},
+		{
+			name:    "Too many lines",
+			content: "1\n2\n3\n4\n5\n6\n7",
+			want:    "1\n2\n3\n4\n5\n…",
+		},
+		{
+			name:    "Too many characters",
+			content: strings.Repeat("é", 301),
+			want:    strings.Repeat("é", 300) + "\n…",
+		},
+	}
+
+	// Loop over the test cases.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Call the snippetPreview function with the test case input.
+			preview := snippetPreview(tt.content)
+
+			// Assert that the result matches the expected output.
+			assert.Equal(t, preview, tt.want)
+		})
+	}
+}
+
+// TestSplitLines tests that highlighted code is split into lines, without an empty line
+// after a final line break.
+func TestSplitLines(t *testing.T) {
+	lines := splitLines(template.HTML("<span class=\"hljs-comment\">// a</span>\n\nb\n"))
+
+	assert.Equal(t, len(lines), 3)
+	assert.Equal(t, lines[0], template.HTML("<span class=\"hljs-comment\">// a</span>"))
+	assert.Equal(t, lines[1], template.HTML(""))
+	assert.Equal(t, lines[2], template.HTML("b"))
+}
@@ -10,11 +10,13 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/go-playground/form/v4"
+	"ssnipp.com/internal/highlight"
 	"ssnipp.com/internal/models/mocks"
 )
 
@@ -37,12 +39,16 @@ func newTestApplication(t *testing.T) *application {
 	// Return the application instance with mocked dependencies.
 	return &application{
 		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
-		snippets:       &mocks.SnippetModel{}, // Use the mock.
-		users:          &mocks.UserModel{},    // Use the mock.
+		snippets:       &mocks.SnippetModel{},    // Use the mock.
+		users:          &mocks.UserModel{},       // Use the mock.
+		tokens:         &mocks.TokenModel{},      // Use the mock.
+		collections:    &mocks.CollectionModel{}, // Use the mock.
 		templateCache:  templateCache,
+		highlighter:    highlight.NewCache(highlightCacheSize),
 		formDecoder:    formDecoder,
 		sessionManager: sessionManager,
 		allowSignup:    true,
+		embedAncestors: "*",
 	}
 }
 
@@ -125,3 +131,59 @@ func (ts *testServer) postForm(t *testing.T, urlPath string, form url.Values) (i
 	// Return the response status, headers and body.
 	return rs.StatusCode, rs.Header, string(body)
 }
+
+// request makes a request with the given method and JSON body to a given URL path using
+// the test server client, and returns the response status code, headers, and body. No body
+// is sent if the body is empty.
+func (ts *testServer) request(t *testing.T, method, urlPath, body string) (int, http.Header, string) {
+	return ts.requestWithToken(t, method, urlPath, "", body)
+}
+
+// requestWithToken makes a request like request, authenticated with the given personal
+// API token in an Authorization header. No header is sent if the token is empty.
+func (ts *testServer) requestWithToken(t *testing.T, method, urlPath, token, body string) (int, http.Header, string) {
+	req, err := http.NewRequest(method, ts.URL+urlPath, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rs, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rs.Body.Close()
+	rsBody, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsBody = bytes.TrimSpace(rsBody)
+
+	return rs.StatusCode, rs.Header, string(rsBody)
+}
+
+// login logs the test server client in as the mocked user with the given email
+// address, so that subsequent requests are made with an authenticated session.
+func (ts *testServer) login(t *testing.T, email string) {
+	// Make a GET request to the /login endpoint to retrieve a valid CSRF token.
+	_, _, body := ts.get(t, "/login")
+
+	form := url.Values{}
+	form.Add("email", email)
+	form.Add("password", "pa$$word")
+	form.Add("csrf_token", extractCSRFToken(t, body))
+
+	// Post the login form and check that the user has been redirected.
+	code, _, _ := ts.postForm(t, "/login", form)
+	if code != http.StatusSeeOther {
+		t.Fatalf("login failed with status %d", code)
+	}
+}
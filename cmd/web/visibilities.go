@@ -0,0 +1,46 @@
+package main
+
+import "ssnipp.com/internal/models"
+
+// Visibility represents a visibility option for snippets, with a key and a value.
+type Visibility struct {
+	Key   string
+	Value string
+}
+
+// getVisibilities returns a slice of Visibility structs containing the visibility options.
+// Each Visibility struct contains a key (stored with the snippet) and a value (display name).
+func getVisibilities() []Visibility {
+	pairs := []Visibility{
+		{Key: models.VisibilityUnlisted, Value: "Unlisted"},
+		{Key: models.VisibilityPublic, Value: "Public"},
+		{Key: models.VisibilityPrivate, Value: "Private"},
+	}
+	return pairs
+}
+
+// getVisibilityKeys returns the keys of the visibility options.
+func getVisibilityKeys() []string {
+	// Retrieve the list of visibilities.
+	visibilities := getVisibilities()
+
+	// Initialize a slice to hold the keys.
+	keys := make([]string, len(visibilities))
+
+	// Populate the slice with the keys of the visibilities.
+	for i, v := range visibilities {
+		keys[i] = v.Key
+	}
+	return keys
+}
+
+// getVisibilityLabel returns the display name of a visibility given its key.
+// If the key does not match any visibility, it returns the key itself.
+func getVisibilityLabel(key string) string {
+	for _, v := range getVisibilities() {
+		if v.Key == key {
+			return v.Value
+		}
+	}
+	return key
+}
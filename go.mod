@@ -16,6 +16,7 @@ require golang.org/x/crypto v0.26.0
 require (
 	github.com/joho/godotenv v1.5.1
 	github.com/justinas/nosurf v1.1.1
+	github.com/yuin/goldmark v1.8.6
 )
 
 require (
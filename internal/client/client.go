@@ -0,0 +1,175 @@
+// Package client implements a client for the JSON API of an ssnipp server, authenticated
+// with a personal API token.
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when the requested snippet doesn't exist, or isn't visible
+// to the owner of the token.
+var ErrNotFound = errors.New("client: snippet not found")
+
+// Snippet is a snippet as returned by the API.
+type Snippet struct {
+	Slug              string     `json:"slug"`
+	Content           string     `json:"content"`
+	Language          string     `json:"language"`
+	Author            string     `json:"author"`
+	Created           time.Time  `json:"created"`
+	Expires           *time.Time `json:"expires"`
+	BurnAfterRead     bool       `json:"burn_after_read"`
+	Visibility        string     `json:"visibility"`
+	PasswordProtected bool       `json:"password_protected"`
+	Encrypted         bool       `json:"encrypted"`
+	Tags              []string   `json:"tags"`
+	Title             string     `json:"title"`
+	Description       string     `json:"description"`
+	Filename          string     `json:"filename"`
+	Files             []File     `json:"files"`
+}
+
+// File is an additional file of a snippet. The main file is described by the Content,
+// Language and Filename fields of the snippet itself.
+type File struct {
+	Filename string `json:"filename,omitempty"`
+	Language string `json:"language,omitempty"`
+	Content  string `json:"content"`
+}
+
+// NewSnippet holds the fields of a snippet to create. Empty fields are left for the
+// server to default.
+type NewSnippet struct {
+	Content     string   `json:"content"`
+	Language    string   `json:"language,omitempty"`
+	Expires     string   `json:"expires,omitempty"`
+	Visibility  string   `json:"visibility,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Filename    string   `json:"filename,omitempty"`
+	Files       []File   `json:"files,omitempty"`
+}
+
+// Client sends requests to the API of the server at BaseURL, such as
+// "https://ssnipp.com", authenticated with Token.
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// APIError is an error response sent by the API.
+type APIError struct {
+	StatusCode  int
+	Message     string            `json:"error"`
+	FieldErrors map[string]string `json:"field_errors"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("server responded with %d: %s", e.StatusCode, e.Message)
+
+	// Sort the fields so that the message is always the same.
+	fields := make([]string, 0, len(e.FieldErrors))
+	for field := range e.FieldErrors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		msg += fmt.Sprintf("; %s: %s", field, e.FieldErrors[field])
+	}
+
+	return msg
+}
+
+// Create creates a snippet, returning it as stored by the server.
+func (c *Client) Create(s NewSnippet) (Snippet, error) {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return Snippet{}, err
+	}
+
+	var data struct {
+		Snippet Snippet `json:"snippet"`
+	}
+
+	err = c.do(http.MethodPost, "/api/v1/snippets", body, &data)
+	if err != nil {
+		return Snippet{}, err
+	}
+
+	return data.Snippet, nil
+}
+
+// Get retrieves the snippet with the given slug.
+func (c *Client) Get(slug string) (Snippet, error) {
+	var data struct {
+		Snippet Snippet `json:"snippet"`
+	}
+
+	err := c.do(http.MethodGet, "/api/v1/snippets/"+url.PathEscape(slug), nil, &data)
+	if err != nil {
+		return Snippet{}, err
+	}
+
+	return data.Snippet, nil
+}
+
+// ViewURL returns the URL of the page showing the snippet with the given slug.
+func (c *Client) ViewURL(slug string) string {
+	return strings.TrimSuffix(c.BaseURL, "/") + "/view/" + url.PathEscape(slug)
+}
+
+// do sends a request to the API with an optional JSON body, and decodes the JSON response
+// into dst. Error responses are returned as an *APIError, or ErrNotFound for a 404.
+func (c *Client) do(method, path string, body []byte, dst any) error {
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.BaseURL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	rs, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rs.Body.Close()
+
+	if rs.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	if rs.StatusCode < 200 || rs.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: rs.StatusCode}
+
+		// Fall back to the status text if the body isn't a JSON error.
+		if json.NewDecoder(rs.Body).Decode(apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(rs.StatusCode)
+		}
+
+		return apiErr
+	}
+
+	return json.NewDecoder(rs.Body).Decode(dst)
+}
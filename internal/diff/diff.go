@@ -0,0 +1,343 @@
+// Package diff computes line-based differences between two texts and groups
+// them into unified diff hunks.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op describes how a line changed between the old and the new text.
+type Op int
+
+const (
+	// Equal lines are present in both texts.
+	Equal Op = iota
+	// Insert lines are only present in the new text.
+	Insert
+	// Delete lines are only present in the old text.
+	Delete
+)
+
+// Line is a single line of a diff. OldNumber and NewNumber are the 1-based line
+// numbers in the old and new texts, and are 0 when the line is absent from that text.
+type Line struct {
+	Op        Op
+	Text      string
+	OldNumber int
+	NewNumber int
+}
+
+// Hunk is a group of changed lines surrounded by unchanged context lines, as shown
+// in unified diffs.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// Header returns the "@@ -l,s +l,s @@" range information of the hunk.
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// splitLines splits a text into lines, normalizing Windows line endings as sent by
+// browsers in textarea submissions. A trailing newline doesn't start a new line.
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
+
+// Lines returns the line-by-line difference between the old and the new text,
+// including the lines that didn't change.
+func Lines(old, new string) []Line {
+	a, b := splitLines(old), splitLines(new)
+
+	d := &differ{
+		a:      a,
+		b:      b,
+		lines:  make([]Line, 0, len(a)+len(b)),
+		budget: maxCost,
+	}
+
+	// Compare lines by number rather than by text, so that long lines which only
+	// differ at their end are compared only once.
+	ids := make(map[string]int)
+	d.aIDs, d.bIDs = lineIDs(a, ids), lineIDs(b, ids)
+
+	d.compare(0, len(a), 0, len(b))
+
+	return d.lines
+}
+
+// lineIDs returns the number of each line in ids, adding the lines not seen yet.
+func lineIDs(lines []string, ids map[string]int) []int {
+	numbers := make([]int, len(lines))
+	for i, line := range lines {
+		id, ok := ids[line]
+		if !ok {
+			id = len(ids)
+			ids[line] = id
+		}
+		numbers[i] = id
+	}
+
+	return numbers
+}
+
+// maxCost is the number of steps the search for the shortest edit script may take for
+// a whole diff. Once it is spent, the remaining changes are shown as the deletion of the
+// old lines followed by the insertion of the new ones, which is still a valid diff if
+// not the shortest, so that texts with many changes are compared in bounded time.
+const maxCost = 4_000_000
+
+// differ computes the difference between the lines of the old and the new text,
+// appending it to lines. The budget is what remains of maxCost.
+type differ struct {
+	a, b       []string
+	aIDs, bIDs []int
+	lines      []Line
+	budget     int
+}
+
+// equal appends the lines from x in a and y in b, which are equal, up to the end of a.
+func (d *differ) equal(x, y, end int) {
+	for ; x < end; x, y = x+1, y+1 {
+		d.lines = append(d.lines, Line{Op: Equal, Text: d.a[x], OldNumber: x + 1, NewNumber: y + 1})
+	}
+}
+
+// compare appends the difference between a[aLo:aHi] and b[bLo:bHi].
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	// Skip the common prefix and suffix, which are very common when comparing two
+	// revisions of the same text and are cheap to detect.
+	prefix := 0
+	for aLo+prefix < aHi && bLo+prefix < bHi && d.aIDs[aLo+prefix] == d.bIDs[bLo+prefix] {
+		prefix++
+	}
+	d.equal(aLo, bLo, aLo+prefix)
+	aLo, bLo = aLo+prefix, bLo+prefix
+
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.aIDs[aHi-1-suffix] == d.bIDs[bHi-1-suffix] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	if aLo < aHi && bLo < bHi {
+		if x, y, ok := d.bisect(aLo, aHi, bLo, bHi); ok {
+			d.compare(aLo, x, bLo, y)
+			d.compare(x, aHi, y, bHi)
+		} else {
+			d.replace(aLo, aHi, bLo, bHi)
+		}
+	} else {
+		d.replace(aLo, aHi, bLo, bHi)
+	}
+
+	d.equal(aHi, bHi, aHi+suffix)
+}
+
+// replace appends the deletion of a[aLo:aHi] followed by the insertion of b[bLo:bHi].
+func (d *differ) replace(aLo, aHi, bLo, bHi int) {
+	for x := aLo; x < aHi; x++ {
+		d.lines = append(d.lines, Line{Op: Delete, Text: d.a[x], OldNumber: x + 1})
+	}
+	for y := bLo; y < bHi; y++ {
+		d.lines = append(d.lines, Line{Op: Insert, Text: d.b[y], NewNumber: y + 1})
+	}
+}
+
+// bisect finds the middle of the shortest edit script between a[aLo:aHi] and
+// b[bLo:bHi] with Myers' O(ND) algorithm, running it from both ends at once until
+// the two paths overlap. It returns the point where they do, which splits the texts
+// into two halves that can be compared separately, so that only the furthest point
+// reached on each diagonal needs to be kept and memory grows linearly with the texts.
+// It reports false if the texts have no line in common, or if the budget of the differ
+// is spent before the middle is found.
+func (d *differ) bisect(aLo, aHi, bLo, bHi int) (x, y int, ok bool) {
+	n, m := aHi-aLo, bHi-bLo
+	a, b := d.aIDs[aLo:aHi], d.bIDs[bLo:bHi]
+
+	// vf and vb hold, for each diagonal k, the furthest x reached so far from the
+	// start and from the end of the texts, or -1. Diagonals are offset by maxD to
+	// index the slices.
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	vf := make([]int, 2*maxD+2)
+	vb := make([]int, 2*maxD+2)
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	// When the difference in length is odd, the paths can only overlap while going
+	// forward, and otherwise while going backward.
+	delta := n - m
+	front := delta%2 != 0
+
+	// Diagonals which went past the end of either text are not explored any further.
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+
+	for D := 0; D < maxD; D++ {
+		// Each round explores up to D+1 diagonals in both directions, and the lines
+		// followed along them are counted as they are compared.
+		d.budget -= 2 * (D + 1)
+		if d.budget < 0 {
+			return 0, 0, false
+		}
+
+		for k := -D + fStart; k <= D-fEnd; k += 2 {
+			i := offset + k
+			var x1 int
+			if k == -D || (k != D && vf[i-1] < vf[i+1]) {
+				x1 = vf[i+1]
+			} else {
+				x1 = vf[i-1] + 1
+			}
+
+			y1 := x1 - k
+			for x1 < n && y1 < m && a[x1] == b[y1] {
+				x1++
+				y1++
+				d.budget--
+			}
+			vf[i] = x1
+
+			switch {
+			case x1 > n:
+				fEnd += 2
+			case y1 > m:
+				fStart += 2
+			case front:
+				j := offset + delta - k
+				if j >= 0 && j < len(vb) && vb[j] != -1 && x1 >= n-vb[j] {
+					return aLo + x1, bLo + y1, true
+				}
+			}
+		}
+
+		for k := -D + bStart; k <= D-bEnd; k += 2 {
+			i := offset + k
+			var x2 int
+			if k == -D || (k != D && vb[i-1] < vb[i+1]) {
+				x2 = vb[i+1]
+			} else {
+				x2 = vb[i-1] + 1
+			}
+
+			y2 := x2 - k
+			for x2 < n && y2 < m && a[n-x2-1] == b[m-y2-1] {
+				x2++
+				y2++
+				d.budget--
+			}
+			vb[i] = x2
+
+			switch {
+			case x2 > n:
+				bEnd += 2
+			case y2 > m:
+				bStart += 2
+			case !front:
+				j := offset + delta - k
+				if j >= 0 && j < len(vf) && vf[j] != -1 {
+					x1 := vf[j]
+					y1 := offset + x1 - j
+					if x1 >= n-x2 {
+						return aLo + x1, bLo + y1, true
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// Unified returns the differences between the old and the new text grouped into
+// hunks, each with up to context unchanged lines before and after its changes.
+// It returns no hunks if the texts have the same lines.
+func Unified(old, new string, context int) []Hunk {
+	lines := Lines(old, new)
+
+	var hunks []Hunk
+
+	// oldNumber and newNumber are the last line numbers seen before the line at pos,
+	// where the next hunk starts its ranges if they contain no lines.
+	pos, oldNumber, newNumber := 0, 0, 0
+
+	i := 0
+	for i < len(lines) {
+		// Find the next changed line.
+		if lines[i].Op == Equal {
+			i++
+			continue
+		}
+
+		start := max(i-context, 0)
+
+		// Extend the hunk until there are more than 2*context unchanged lines in
+		// a row, in which case the next change gets a hunk of its own.
+		end := i
+		for end < len(lines) {
+			if lines[end].Op != Equal {
+				end++
+				continue
+			}
+
+			run := end
+			for run < len(lines) && lines[run].Op == Equal {
+				run++
+			}
+
+			if run == len(lines) || run-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+
+			end = run
+		}
+
+		for ; pos < start; pos++ {
+			oldNumber = max(oldNumber, lines[pos].OldNumber)
+			newNumber = max(newNumber, lines[pos].NewNumber)
+		}
+
+		hunks = append(hunks, newHunk(oldNumber, newNumber, lines[start:end]))
+		i = end
+	}
+
+	return hunks
+}
+
+// newHunk builds a hunk from a contiguous slice of diff lines, computing its ranges.
+// By convention, an empty range starts at the line before the hunk, so the last old and
+// new line numbers before the hunk are needed to position ranges that contain no lines.
+func newHunk(oldBefore, newBefore int, lines []Line) Hunk {
+	h := Hunk{OldStart: oldBefore, NewStart: newBefore, Lines: lines}
+
+	for _, l := range lines {
+		if l.OldNumber > 0 {
+			if h.OldLines == 0 {
+				h.OldStart = l.OldNumber
+			}
+			h.OldLines++
+		}
+		if l.NewNumber > 0 {
+			if h.NewLines == 0 {
+				h.NewStart = l.NewNumber
+			}
+			h.NewLines++
+		}
+	}
+
+	return h
+}
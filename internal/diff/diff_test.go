@@ -0,0 +1,226 @@
+package diff
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"ssnipp.com/internal/assert"
+)
+
+// render formats diff lines the way a unified diff does, one line per entry
+// prefixed with " ", "+" or "-", so the test cases are easy to read.
+func render(lines []Line) string {
+	var b strings.Builder
+
+	for _, l := range lines {
+		switch l.Op {
+		case Insert:
+			b.WriteString("+")
+		case Delete:
+			b.WriteString("-")
+		default:
+			b.WriteString(" ")
+		}
+		b.WriteString(l.Text)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// TestLines tests the Lines function with a variety of edits.
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string // Name of the test case.
+		old  string // Old text.
+		new  string // New text.
+		want string // Expected diff, rendered with the render helper.
+	}{
+		{
+			name: "Identical",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: " a\n b\n",
+		},
+		{
+			name: "Both empty",
+			old:  "",
+			new:  "",
+			want: "",
+		},
+		{
+			name: "From empty",
+			old:  "",
+			new:  "a\nb",
+			want: "+a\n+b\n",
+		},
+		{
+			name: "To empty",
+			old:  "a\nb",
+			new:  "",
+			want: "-a\n-b\n",
+		},
+		{
+			name: "Changed line",
+			old:  "a\nb\nc",
+			new:  "a\nB\nc",
+			want: " a\n-b\n+B\n c\n",
+		},
+		{
+			name: "Inserted and deleted lines",
+			old:  "a\nb\nc\nd",
+			new:  "b\nc\nx\nd",
+			want: "-a\n b\n c\n+x\n d\n",
+		},
+		{
+			name: "Windows line endings",
+			old:  "a\r\nb\r\n",
+			new:  "a\nb\n",
+			want: " a\n b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, render(Lines(tt.old, tt.new)), tt.want)
+		})
+	}
+}
+
+// TestLinesNumbers tests that Lines numbers each line in the old and new texts.
+func TestLinesNumbers(t *testing.T) {
+	lines := Lines("a\nb\nc", "a\nx\ny\nc")
+
+	want := []Line{
+		{Op: Equal, Text: "a", OldNumber: 1, NewNumber: 1},
+		{Op: Delete, Text: "b", OldNumber: 2},
+		{Op: Insert, Text: "x", NewNumber: 2},
+		{Op: Insert, Text: "y", NewNumber: 3},
+		{Op: Equal, Text: "c", OldNumber: 3, NewNumber: 4},
+	}
+
+	assert.Equal(t, len(lines), len(want))
+	for i := range want {
+		assert.Equal(t, lines[i], want[i])
+	}
+}
+
+// TestLinesUnrelated tests that Lines compares large texts with no line in common
+// using memory proportional to their length, rather than to the square of the number
+// of edits.
+func TestLinesUnrelated(t *testing.T) {
+	var old, new strings.Builder
+	for i := range 5000 {
+		fmt.Fprintf(&old, "old line %d\n", i)
+		fmt.Fprintf(&new, "new line %d\n", i)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	lines := Lines(old.String(), new.String())
+	runtime.ReadMemStats(&after)
+
+	assert.Equal(t, len(lines), 10000)
+	assert.Equal(t, lines[0], Line{Op: Delete, Text: "old line 0", OldNumber: 1})
+	assert.Equal(t, lines[9999], Line{Op: Insert, Text: "new line 4999", NewNumber: 5000})
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+		t.Errorf("allocated %d bytes; want at most %d", allocated, 16<<20)
+	}
+}
+
+// TestLinesCost tests that Lines stops looking for the shortest edit script of texts
+// with many changes once its budget is spent, still returning a valid diff.
+func TestLinesCost(t *testing.T) {
+	var old, new strings.Builder
+	for i := range 160000 {
+		fmt.Fprintf(&old, "line %d\n", i)
+		if i%10 == 0 {
+			fmt.Fprintf(&new, "changed %d\n", i)
+		} else {
+			fmt.Fprintf(&new, "line %d\n", i)
+		}
+	}
+
+	start := time.Now()
+	lines := Lines(old.String(), new.String())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %s; want less than a second", elapsed)
+	}
+
+	// Applying the diff must give back both texts.
+	var gotOld, gotNew strings.Builder
+	for _, l := range lines {
+		if l.Op != Insert {
+			gotOld.WriteString(l.Text + "\n")
+		}
+		if l.Op != Delete {
+			gotNew.WriteString(l.Text + "\n")
+		}
+	}
+	assert.Equal(t, gotOld.String(), old.String())
+	assert.Equal(t, gotNew.String(), new.String())
+}
+
+// TestUnified tests that Unified groups changes into hunks with context lines.
+func TestUnified(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+
+	tests := []struct {
+		name    string   // Name of the test case.
+		new     string   // New text, compared with old.
+		context int      // Number of context lines.
+		want    []string // Expected hunk headers.
+	}{
+		{
+			name:    "No changes",
+			new:     old,
+			context: 3,
+			want:    nil,
+		},
+		{
+			name:    "Single change",
+			new:     "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n",
+			context: 1,
+			want:    []string{"@@ -4,3 +4,3 @@"},
+		},
+		{
+			name:    "Distant changes",
+			new:     "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			context: 2,
+			want:    []string{"@@ -1,3 +1,3 @@", "@@ -8,3 +8,3 @@"},
+		},
+		{
+			name:    "Close changes",
+			new:     "1\n2\nthree\n4\n5\nsix\n7\n8\n9\n10\n",
+			context: 2,
+			want:    []string{"@@ -1,8 +1,8 @@"},
+		},
+		{
+			name:    "Pure insertion",
+			new:     "1\n2\n3\n4\n5\nnew\n6\n7\n8\n9\n10\n",
+			context: 0,
+			want:    []string{"@@ -5,0 +6,1 @@"},
+		},
+		{
+			name:    "Pure deletion after a shift",
+			new:     "0\n1\n2\n3\n4\n6\n7\n8\n9\n10\n",
+			context: 0,
+			want:    []string{"@@ -0,0 +1,1 @@", "@@ -5,1 +5,0 @@"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hunks := Unified(old, tt.new, tt.context)
+
+			assert.Equal(t, len(hunks), len(tt.want))
+			for i := range min(len(hunks), len(tt.want)) {
+				assert.Equal(t, hunks[i].Header(), tt.want[i])
+			}
+		})
+	}
+}
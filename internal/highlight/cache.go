@@ -0,0 +1,86 @@
+package highlight
+
+import (
+	"container/list"
+	"html/template"
+	"sync"
+)
+
+// Cache keeps the highlighted HTML of recently viewed snippets, so that popular snippets
+// aren't highlighted again on every view. Entries are looked up by a key chosen by the
+// caller, such as the ID of the snippet, and are only used while the code and language
+// they were highlighted from are unchanged, so edited snippets are highlighted again.
+// The least recently used entries are dropped once the cache is full. It is safe for
+// concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	recent  *list.List
+}
+
+// cacheEntry is the highlighted HTML of the code of a Cache entry.
+type cacheEntry struct {
+	key      string
+	code     string
+	language string
+	html     template.HTML
+}
+
+// NewCache returns a Cache holding up to size entries.
+func NewCache(size int) *Cache {
+	return &Cache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		recent:  list.New(),
+	}
+}
+
+// HTML returns the code highlighted like the HTML function, from the cache if it was
+// highlighted under the same key before.
+func (c *Cache) HTML(key, code, language string) template.HTML {
+	return c.Render(key, code, language, HTML)
+}
+
+// Render is like HTML, but converts the code to HTML with the given function, such as a
+// Markdown renderer. Keys must not be shared with code converted by another function.
+func (c *Cache) Render(key, code, language string, render func(code, language string) template.HTML) template.HTML {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		entry := e.Value.(*cacheEntry)
+		if entry.code == code && entry.language == language {
+			c.recent.MoveToFront(e)
+			c.mu.Unlock()
+			return entry.html
+		}
+	}
+	c.mu.Unlock()
+
+	// Highlight the code without holding the lock, as it can take a while for long
+	// snippets.
+	html := render(code, language)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.recent.Remove(e)
+	}
+	c.entries[key] = c.recent.PushFront(&cacheEntry{key: key, code: code, language: language, html: html})
+
+	for c.recent.Len() > c.size {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+
+	return html
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.recent.Len()
+}
@@ -0,0 +1,182 @@
+package highlight
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Class names of the tokens of data and document formats.
+const (
+	classSection  = "hljs-section"
+	classBullet   = "hljs-bullet"
+	classQuote    = "hljs-quote"
+	classCode     = "hljs-code"
+	classStrong   = "hljs-strong"
+	classEmphasis = "hljs-emphasis"
+	classLink     = "hljs-link"
+	classAddition = "hljs-addition"
+	classDeletion = "hljs-deletion"
+)
+
+// eachLine calls fn with every line of the code, without its line break, writing the
+// line breaks in between.
+func eachLine(w *writer, code string, fn func(line string)) {
+	for i, line := range strings.Split(code, "\n") {
+		if i > 0 {
+			w.plain("\n")
+		}
+		fn(line)
+	}
+}
+
+// lexDiff writes unified diffs highlighted, one line at a time.
+func lexDiff(w *writer, code string) {
+	eachLine(w, code, func(line string) {
+		switch {
+		case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "@@"),
+			strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "index "):
+			w.token(classMeta, line)
+		case strings.HasPrefix(line, "+"), strings.HasPrefix(line, ">"):
+			w.token(classAddition, line)
+		case strings.HasPrefix(line, "-"), strings.HasPrefix(line, "<"):
+			w.token(classDeletion, line)
+		default:
+			w.plain(line)
+		}
+	})
+}
+
+// yamlValue and tomlValue describe the values of YAML and TOML documents, whose keys
+// are picked out line by line.
+var (
+	yamlValue = &syntax{
+		lineComments:   []string{"#"},
+		quotes:         []quote{{delim: `"`, escapes: true}, {delim: "'"}},
+		literals:       words("true false null yes no on off True False Null Yes No On Off TRUE FALSE NULL"),
+		spacedComments: true,
+	}
+	tomlValue = &syntax{
+		lineComments: []string{"#"},
+		quotes:       []quote{{delim: `"""`, escapes: true}, {delim: "'''"}, {delim: `"`, escapes: true}, {delim: "'"}},
+		literals:     words("true false inf nan"),
+	}
+)
+
+var (
+	// yamlKeyRX matches the key of a YAML mapping entry, after its indentation and
+	// list markers, such as "name:" or "'on':".
+	yamlKeyRX = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s#'"{\[\]},&*!|>%@-][^#]*?|-[^\s#][^#]*?)\s*:(\s|$)`)
+	// tomlKeyRX matches the key of a TOML key/value pair, such as "name =" or
+	// "a.b =".
+	tomlKeyRX = regexp.MustCompile(`^\s*([\w.-]+|"[^"]*"|'[^']*')\s*=`)
+	// tomlTableRX matches the headers of TOML tables and arrays of tables.
+	tomlTableRX = regexp.MustCompile(`^\s*\[\[?[^\]\n]*\]\]?`)
+)
+
+// lexYAML writes YAML documents highlighted, one line at a time.
+func lexYAML(w *writer, code string) {
+	eachLine(w, code, func(line string) {
+		trimmed := strings.TrimRight(line, " \t\r")
+		if trimmed == "---" || trimmed == "..." || strings.HasPrefix(line, "%") {
+			w.token(classMeta, line)
+			return
+		}
+
+		// Write the indentation and the markers of list items
+		i := len(line) - len(strings.TrimLeft(line, " \t"))
+		w.plain(line[:i])
+		for strings.HasPrefix(line[i:], "- ") || line[i:] == "-" {
+			w.token(classBullet, "-")
+			n := 1 + len(line[i+1:]) - len(strings.TrimLeft(line[i+1:], " \t"))
+			w.plain(line[i+1 : i+n])
+			i += n
+		}
+
+		if m := yamlKeyRX.FindStringSubmatchIndex(line[i:]); m != nil {
+			w.token(classAttr, line[i:i+m[3]])
+			i += m[3]
+		}
+
+		yamlValue.lex(w, line[i:])
+	})
+}
+
+// lexTOML writes TOML documents highlighted, one line at a time.
+func lexTOML(w *writer, code string) {
+	eachLine(w, code, func(line string) {
+		i := 0
+		if m := tomlTableRX.FindStringIndex(line); m != nil {
+			w.token(classSection, line[:m[1]])
+			i = m[1]
+		} else if m := tomlKeyRX.FindStringSubmatchIndex(line); m != nil {
+			w.plain(line[:m[2]])
+			w.token(classAttr, line[m[2]:m[3]])
+			i = m[3]
+		}
+
+		tomlValue.lex(w, line[i:])
+	})
+}
+
+var (
+	// markdownFenceRX matches the lines opening and closing fenced code blocks.
+	markdownFenceRX = regexp.MustCompile("^ {0,3}(```|~~~)")
+	// markdownBulletRX matches the markers of list items, such as "- " or "1. ".
+	markdownBulletRX = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s`)
+	// markdownInlineRX matches inline code, strong and emphasized text, and links.
+	markdownInlineRX = regexp.MustCompile("`[^`]+`|\\*\\*[^*]+\\*\\*|__[^_]+__|\\*[^*\\s][^*]*\\*|\\b_[^_\\s][^_]*_\\b|\\[[^\\]]*\\]\\([^)\\s]*\\)")
+)
+
+// lexMarkdown writes Markdown documents highlighted, one line at a time.
+func lexMarkdown(w *writer, code string) {
+	fenced := false
+
+	eachLine(w, code, func(line string) {
+		switch {
+		case markdownFenceRX.MatchString(line):
+			fenced = !fenced
+			w.token(classCode, line)
+		case fenced:
+			w.token(classCode, line)
+		case strings.HasPrefix(line, "#"):
+			w.token(classSection, line)
+		case strings.HasPrefix(line, ">"):
+			w.token(classQuote, line)
+		default:
+			if m := markdownBulletRX.FindStringSubmatchIndex(line); m != nil {
+				w.plain(line[:m[2]])
+				w.token(classBullet, line[m[2]:m[3]])
+				line = line[m[3]:]
+			}
+			lexMarkdownInline(w, line)
+		}
+	})
+}
+
+// lexMarkdownInline writes the inline markup of a line of Markdown highlighted.
+func lexMarkdownInline(w *writer, line string) {
+	last := 0
+	for _, m := range markdownInlineRX.FindAllStringIndex(line, -1) {
+		w.plain(line[last:m[0]])
+		token := line[m[0]:m[1]]
+		last = m[1]
+
+		switch {
+		case token[0] == '`':
+			w.token(classCode, token)
+		case token[0] == '[':
+			text := strings.Index(token, "](")
+			w.plain("[")
+			w.token(classString, token[1:text])
+			w.plain("](")
+			w.token(classLink, token[text+2:len(token)-1])
+			w.plain(")")
+		case strings.HasPrefix(token, "**"), strings.HasPrefix(token, "__"):
+			w.token(classStrong, token)
+		default:
+			w.token(classEmphasis, token)
+		}
+	}
+
+	w.plain(line[last:])
+}
@@ -0,0 +1,474 @@
+// Package highlight turns source code into HTML annotated with the class names used by
+// highlight.js, so that snippets can be highlighted on the server and styled with the
+// same stylesheets. Languages are tokenized rather than parsed: comments, strings,
+// numbers and keywords are recognised, which is all that matters for display.
+package highlight
+
+import (
+	"html"
+	"html/template"
+	"strings"
+	"unicode/utf8"
+)
+
+// Supported reports whether the language has highlighting rules. Other languages, such
+// as plaintext, are still accepted by HTML, which escapes them without any markup.
+func Supported(language string) bool {
+	_, ok := lexers[language]
+	return ok
+}
+
+// HTML returns the code as escaped HTML, with its tokens wrapped in spans carrying the
+// highlight.js class names of the given language. Code in an unknown language is
+// escaped as plain text. Spans never cross line breaks, so that the result can be split
+// into lines.
+func HTML(code, language string) template.HTML {
+	var w writer
+
+	lex, ok := lexers[language]
+	if !ok {
+		w.plain(code)
+		return template.HTML(w.b.String())
+	}
+
+	lex(&w, code)
+	return template.HTML(w.b.String())
+}
+
+// writer accumulates the highlighted HTML.
+type writer struct {
+	b strings.Builder
+}
+
+// plain writes text without any markup.
+func (w *writer) plain(s string) {
+	w.b.WriteString(html.EscapeString(s))
+}
+
+// token writes text wrapped in a span with the given class names, closing the span
+// before each line break and opening it again after it. Text without a class is
+// written as it is.
+func (w *writer) token(class, s string) {
+	if class == "" {
+		w.plain(s)
+		return
+	}
+
+	for i, line := range strings.Split(s, "\n") {
+		if i > 0 {
+			w.b.WriteByte('\n')
+		}
+		if line == "" {
+			continue
+		}
+
+		w.b.WriteString(`<span class="`)
+		w.b.WriteString(class)
+		w.b.WriteString(`">`)
+		w.plain(line)
+		w.b.WriteString(`</span>`)
+	}
+}
+
+// Class names of the tokens, as used by highlight.js themes.
+const (
+	classComment  = "hljs-comment"
+	classString   = "hljs-string"
+	classNumber   = "hljs-number"
+	classKeyword  = "hljs-keyword"
+	classLiteral  = "hljs-literal"
+	classBuiltIn  = "hljs-built_in"
+	classType     = "hljs-type"
+	classVariable = "hljs-variable"
+	classMeta     = "hljs-meta"
+	classAttr     = "hljs-attr"
+	classSymbol   = "hljs-symbol"
+	classFunction = "hljs-title function_"
+	classClass    = "hljs-title class_"
+)
+
+// quote describes the delimiter of a kind of string literal.
+type quote struct {
+	delim     string
+	close     string // The closing delimiter, if it differs from the opening one.
+	escapes   bool   // Backslashes escape the next character.
+	multiline bool   // The string can span several lines.
+}
+
+// syntax describes the lexical structure of a programming language closely enough to
+// pick out its tokens.
+type syntax struct {
+	lineComments  []string
+	blockComments [][2]string
+	quotes        []quote // Longest delimiters first.
+	metas         []string
+	keywords      set
+	literals      set
+	types         set
+	builtIns      set
+	// titles maps the keywords introducing a definition, such as "func", to the class
+	// of the name which follows them.
+	titles map[string]string
+	// variables lists the sigils starting variable names, such as "$".
+	variables string
+	// caseInsensitive languages match keywords regardless of their case.
+	caseInsensitive bool
+	// spacedComments languages only start line comments at the beginning of a word,
+	// as "#" is also used in the middle of words in shell scripts.
+	spacedComments bool
+	// preprocessor languages treat lines starting with "#" as directives.
+	preprocessor bool
+	// annotations languages treat "@" followed by a name as an annotation.
+	annotations bool
+	// symbols languages treat ":" followed by a name as a symbol, like in Ruby.
+	symbols bool
+	// macros languages treat names followed by "!" as built-in macros, like in Rust.
+	macros bool
+	// lifetimes languages only read "'" as a character literal when it is closed
+	// right after a single character, and as a lifetime otherwise, like in Rust.
+	lifetimes bool
+	// keys languages treat strings followed by ":" as attribute names, like in JSON.
+	keys bool
+}
+
+// set is a set of words.
+type set map[string]bool
+
+// words returns the set of the space-separated words.
+func words(s string) set {
+	m := make(set)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+// lex writes the code highlighted according to the syntax.
+func (s *syntax) lex(w *writer, code string) {
+	lineStart := true
+
+	for i := 0; i < len(code); {
+		c := code[i]
+		rest := code[i:]
+
+		// Whitespace doesn't change whether the line has started
+		if c == '\n' || c == ' ' || c == '\t' || c == '\r' {
+			w.plain(code[i : i+1])
+			if c == '\n' {
+				lineStart = true
+			}
+			i++
+			continue
+		}
+
+		atLineStart := lineStart
+		lineStart = false
+
+		// Shebangs and preprocessor directives run until the end of the line
+		if (i == 0 && strings.HasPrefix(code, "#!")) || (atLineStart && s.preprocessor && c == '#') {
+			end := i + lineEnd(rest)
+			w.token(classMeta, code[i:end])
+			i = end
+			continue
+		}
+
+		if n := prefixLen(rest, s.metas); n > 0 {
+			w.token(classMeta, rest[:n])
+			i += n
+			continue
+		}
+
+		if n := s.comment(code, i); n > 0 {
+			w.token(classComment, rest[:n])
+			i += n
+			continue
+		}
+
+		if n := s.string(rest); n > 0 {
+			class := classString
+			if s.keys && strings.HasPrefix(strings.TrimLeft(rest[n:], " \t"), ":") {
+				class = classAttr
+			}
+			w.token(class, rest[:n])
+			i += n
+			continue
+		}
+
+		if s.lifetimes && c == '\'' && isIdentStart(next(rest, 1)) {
+			n := 1 + identLen(rest[1:])
+			w.token(classSymbol, rest[:n])
+			i += n
+			continue
+		}
+
+		if strings.IndexByte(s.variables, c) >= 0 {
+			if n := variableLen(rest); n > 0 {
+				w.token(classVariable, rest[:n])
+				i += n
+				continue
+			}
+		}
+
+		if s.annotations && c == '@' && isIdentStart(next(rest, 1)) {
+			n := 1 + identLen(rest[1:])
+			for n < len(rest) && rest[n] == '.' && isIdentStart(next(rest, n+1)) {
+				n += 1 + identLen(rest[n+1:])
+			}
+			w.token(classMeta, rest[:n])
+			i += n
+			continue
+		}
+
+		if s.symbols && c == ':' && isIdentStart(next(rest, 1)) && (i == 0 || !isIdent(code[i-1]) && code[i-1] != ':') {
+			n := 1 + identLen(rest[1:])
+			w.token(classSymbol, rest[:n])
+			i += n
+			continue
+		}
+
+		if isNumberStart(rest) && (i == 0 || !isIdent(code[i-1])) {
+			n := numberLen(rest)
+			w.token(classNumber, rest[:n])
+			i += n
+			continue
+		}
+
+		if isIdentStart(c) {
+			n := identLen(rest)
+			word := rest[:n]
+			w.token(s.classify(word, rest[n:]), word)
+			i += n
+
+			// Highlight the name following keywords such as "func"
+			if title, ok := s.titles[s.fold(word)]; ok {
+				spaces := len(code[i:]) - len(strings.TrimLeft(code[i:], " \t"))
+				if isIdentStart(next(code[i:], spaces)) {
+					w.plain(code[i : i+spaces])
+					i += spaces
+					n := identLen(code[i:])
+					w.token(title, code[i:i+n])
+					i += n
+				}
+			}
+			continue
+		}
+
+		w.plain(rest[:1])
+		i++
+	}
+}
+
+// fold returns the word in the case used by the word sets of the syntax.
+func (s *syntax) fold(word string) string {
+	if s.caseInsensitive {
+		return strings.ToLower(word)
+	}
+	return word
+}
+
+// classify returns the class of a word, given the code following it.
+func (s *syntax) classify(word, after string) string {
+	word = s.fold(word)
+
+	switch {
+	case s.keywords[word]:
+		return classKeyword
+	case s.literals[word]:
+		return classLiteral
+	case s.types[word]:
+		return classType
+	case s.builtIns[word]:
+		return classBuiltIn
+	case s.macros && strings.HasPrefix(after, "!") && !strings.HasPrefix(after, "!="):
+		return classBuiltIn
+	}
+
+	return ""
+}
+
+// comment returns the length of the comment starting at position i of the code, or 0
+// if there is none.
+func (s *syntax) comment(code string, i int) int {
+	rest := code[i:]
+
+	for _, delims := range s.blockComments {
+		if strings.HasPrefix(rest, delims[0]) {
+			end := strings.Index(rest[len(delims[0]):], delims[1])
+			if end < 0 {
+				return len(rest)
+			}
+			return len(delims[0]) + end + len(delims[1])
+		}
+	}
+
+	for _, prefix := range s.lineComments {
+		if !strings.HasPrefix(rest, prefix) {
+			continue
+		}
+		if s.spacedComments && i > 0 && !isSpace(code[i-1]) {
+			continue
+		}
+		return lineEnd(rest)
+	}
+
+	return 0
+}
+
+// string returns the length of the string literal at the start of the code, or 0 if
+// there is none. Unterminated strings run until the end of the line, or of the code
+// for multiline strings.
+func (s *syntax) string(code string) int {
+	for _, q := range s.quotes {
+		if !strings.HasPrefix(code, q.delim) {
+			continue
+		}
+
+		if s.lifetimes && q.delim == "'" {
+			return charLen(code)
+		}
+
+		closing := q.close
+		if closing == "" {
+			closing = q.delim
+		}
+
+		for i := len(q.delim); i < len(code); i++ {
+			switch {
+			case q.escapes && code[i] == '\\':
+				i++
+			case strings.HasPrefix(code[i:], closing):
+				return i + len(closing)
+			case code[i] == '\n' && !q.multiline:
+				return i
+			}
+		}
+		return len(code)
+	}
+
+	return 0
+}
+
+// charLen returns the length of the character literal at the start of the code, such as
+// 'a' or '\n', or 0 if the quote doesn't start one.
+func charLen(code string) int {
+	if strings.HasPrefix(code, `'\`) {
+		end := strings.IndexAny(code[2:], "'\n")
+		if end < 0 || code[2+end] != '\'' {
+			return 0
+		}
+		return 2 + end + 1
+	}
+
+	_, size := utf8.DecodeRuneInString(code[1:])
+	if size == 0 || code[1] == '\n' || !strings.HasPrefix(code[1+size:], "'") {
+		return 0
+	}
+	return 1 + size + 1
+}
+
+// maxVariableLen is the maximum length of a ${name} variable, so that looking for the
+// end of unclosed ones doesn't scan the rest of the code every time.
+const maxVariableLen = 256
+
+// variableLen returns the length of the variable at the start of the code, including
+// its sigil, such as $name, ${name} or the special $1 and $? shell variables, or 0 if
+// the sigil isn't followed by a variable name.
+func variableLen(code string) int {
+	c := next(code, 1)
+
+	switch {
+	case isIdentStart(c):
+		return 1 + identLen(code[1:])
+	case c == '{':
+		end := strings.IndexAny(code[:min(len(code), maxVariableLen)], "}\n")
+		if end < 0 || code[end] != '}' {
+			return 0
+		}
+		return end + 1
+	case c >= '0' && c <= '9', strings.IndexByte("@?#*!$-", c) >= 0 && c != 0:
+		return 2
+	}
+
+	return 0
+}
+
+// prefixLen returns the length of the first of the prefixes starting the code, or 0 if
+// it starts with none.
+func prefixLen(code string, prefixes []string) int {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(code, prefix) {
+			return len(prefix)
+		}
+	}
+	return 0
+}
+
+// lineEnd returns the position of the end of the first line of the code, excluding the
+// line break.
+func lineEnd(code string) int {
+	end := strings.IndexByte(code, '\n')
+	if end < 0 {
+		return len(code)
+	}
+	return end
+}
+
+// next returns the byte at position i of the code, or 0 past its end.
+func next(code string, i int) byte {
+	if i < len(code) {
+		return code[i]
+	}
+	return 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentStart reports whether the byte can start a name. Bytes of multibyte UTF-8
+// sequences are treated as letters.
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= utf8.RuneSelf
+}
+
+func isIdent(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+// identLen returns the length of the name at the start of the code.
+func identLen(code string) int {
+	i := 0
+	for i < len(code) && isIdent(code[i]) {
+		i++
+	}
+	return i
+}
+
+// isNumberStart reports whether the code starts with a number, such as 42 or .5.
+func isNumberStart(code string) bool {
+	return isDigit(next(code, 0)) || next(code, 0) == '.' && isDigit(next(code, 1))
+}
+
+// numberLen returns the length of the number at the start of the code, including
+// prefixes, exponents, separators and suffixes such as 0x1F, 1e-9, 1_000 or 10px.
+func numberLen(code string) int {
+	i := 0
+	for i < len(code) {
+		c := code[i]
+		switch {
+		case isIdent(c):
+			if (c == 'e' || c == 'E') && (next(code, i+1) == '-' || next(code, i+1) == '+') && isDigit(next(code, i+2)) {
+				i += 2
+			}
+		case c == '.' && isDigit(next(code, i+1)):
+		default:
+			return i
+		}
+		i++
+	}
+	return i
+}
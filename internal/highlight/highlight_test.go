@@ -0,0 +1,226 @@
+package highlight
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+	"time"
+
+	"ssnipp.com/internal/assert"
+)
+
+// TestHTML tests the HTML function with a variety of languages and tokens.
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name     string // Name of the test case.
+		language string // Language of the code.
+		code     string // Code to highlight.
+		want     string // Expected HTML.
+	}{
+		{
+			name:     "Plain text",
+			language: "plaintext",
+			code:     "<script>alert('hi')</script>",
+			want:     "&lt;script&gt;alert(&#39;hi&#39;)&lt;/script&gt;",
+		},
+		{
+			name:     "Unknown language",
+			language: "cobol",
+			code:     "DISPLAY 'A & B'.",
+			want:     "DISPLAY &#39;A &amp; B&#39;.",
+		},
+		{
+			name:     "Go",
+			language: "go",
+			code:     "func main() {\n\tfmt.Println(len(\"<hi>\"), 0x1F, nil) // Done\n}",
+			want: `<span class="hljs-keyword">func</span> <span class="hljs-title function_">main</span>() {` + "\n" +
+				"\tfmt.Println(" + `<span class="hljs-built_in">len</span>(<span class="hljs-string">&#34;&lt;hi&gt;&#34;</span>), <span class="hljs-number">0x1F</span>, <span class="hljs-literal">nil</span>) <span class="hljs-comment">// Done</span>` + "\n}",
+		},
+		{
+			name:     "Multiline comment",
+			language: "javascript",
+			code:     "/* One\n\ntwo */ x",
+			want:     `<span class="hljs-comment">/* One</span>` + "\n\n" + `<span class="hljs-comment">two */</span> x`,
+		},
+		{
+			name:     "Escaped quote",
+			language: "javascript",
+			code:     `"a\"b" + c`,
+			want:     `<span class="hljs-string">&#34;a\&#34;b&#34;</span> + c`,
+		},
+		{
+			name:     "Unterminated string",
+			language: "python",
+			code:     "x = 'abc\ny = 2",
+			want:     `x = <span class="hljs-string">&#39;abc</span>` + "\n" + `y = <span class="hljs-number">2</span>`,
+		},
+		{
+			name:     "Python decorator",
+			language: "python",
+			code:     "@app.route\ndef index(): pass",
+			want:     `<span class="hljs-meta">@app.route</span>` + "\n" + `<span class="hljs-keyword">def</span> <span class="hljs-title function_">index</span>(): <span class="hljs-keyword">pass</span>`,
+		},
+		{
+			name:     "Shell",
+			language: "bash",
+			code:     "#!/bin/sh\necho \"$HOME\" $1 a#b # Comment",
+			want:     `<span class="hljs-meta">#!/bin/sh</span>` + "\n" + `<span class="hljs-built_in">echo</span> <span class="hljs-string">&#34;$HOME&#34;</span> <span class="hljs-variable">$1</span> a#b <span class="hljs-comment"># Comment</span>`,
+		},
+		{
+			name:     "SQL keywords in any case",
+			language: "sql",
+			code:     "select * FROM t",
+			want:     `<span class="hljs-keyword">select</span> * <span class="hljs-keyword">FROM</span> t`,
+		},
+		{
+			name:     "C preprocessor",
+			language: "c",
+			code:     "#include <stdio.h>\nint x = 1;",
+			want:     `<span class="hljs-meta">#include &lt;stdio.h&gt;</span>` + "\n" + `<span class="hljs-type">int</span> x = <span class="hljs-number">1</span>;`,
+		},
+		{
+			name:     "Rust lifetimes and characters",
+			language: "rust",
+			code:     "fn f<'a>() -> char { 'x' }",
+			want:     `<span class="hljs-keyword">fn</span> <span class="hljs-title function_">f</span>&lt;<span class="hljs-symbol">&#39;a</span>&gt;() -&gt; <span class="hljs-type">char</span> { <span class="hljs-string">&#39;x&#39;</span> }`,
+		},
+		{
+			name:     "JSON keys",
+			language: "json",
+			code:     `{"a": [1.5, true]}`,
+			want:     `{<span class="hljs-attr">&#34;a&#34;</span>: [<span class="hljs-number">1.5</span>, <span class="hljs-literal">true</span>]}`,
+		},
+		{
+			name:     "HTML",
+			language: "html",
+			code:     `<a href="/x" hidden>Hi</a><!-- c -->`,
+			want:     `&lt;<span class="hljs-name">a</span> <span class="hljs-attr">href</span>=<span class="hljs-string">&#34;/x&#34;</span> <span class="hljs-attr">hidden</span>&gt;Hi&lt;/<span class="hljs-name">a</span>&gt;<span class="hljs-comment">&lt;!-- c --&gt;</span>`,
+		},
+		{
+			name:     "HTML script",
+			language: "html",
+			code:     "<script>let a;</script>",
+			want:     `&lt;<span class="hljs-name">script</span>&gt;<span class="hljs-keyword">let</span> a;&lt;/<span class="hljs-name">script</span>&gt;`,
+		},
+		{
+			name:     "CSS",
+			language: "css",
+			code:     "a.b:hover, #c { margin: -1px 50%; color: #fff; }",
+			want:     `<span class="hljs-selector-tag">a</span><span class="hljs-selector-class">.b</span><span class="hljs-selector-pseudo">:hover</span>, <span class="hljs-selector-id">#c</span> { <span class="hljs-attribute">margin</span>: <span class="hljs-number">-1px</span> <span class="hljs-number">50%</span>; <span class="hljs-attribute">color</span>: <span class="hljs-number">#fff</span>; }`,
+		},
+		{
+			name:     "SCSS nesting",
+			language: "scss",
+			code:     "a { $x: 1px; &:hover { top: $x; } }",
+			want:     `<span class="hljs-selector-tag">a</span> { <span class="hljs-variable">$x</span>: <span class="hljs-number">1px</span>; &amp;<span class="hljs-selector-pseudo">:hover</span> { <span class="hljs-attribute">top</span>: <span class="hljs-variable">$x</span>; } }`,
+		},
+		{
+			name:     "YAML",
+			language: "yaml",
+			code:     "---\nsteps:\n  - run: go test # Tests\n    if: true",
+			want:     `<span class="hljs-meta">---</span>` + "\n" + `<span class="hljs-attr">steps</span>:` + "\n" + `  <span class="hljs-bullet">-</span> <span class="hljs-attr">run</span>: go test <span class="hljs-comment"># Tests</span>` + "\n" + `    <span class="hljs-attr">if</span>: <span class="hljs-literal">true</span>`,
+		},
+		{
+			name:     "TOML",
+			language: "toml",
+			code:     "[server]\nport = 8080",
+			want:     `<span class="hljs-section">[server]</span>` + "\n" + `<span class="hljs-attr">port</span> = <span class="hljs-number">8080</span>`,
+		},
+		{
+			name:     "Markdown",
+			language: "markdown",
+			code:     "# Title\n- **a** `b` [c](/d)\n```\n# x\n```",
+			want: `<span class="hljs-section"># Title</span>` + "\n" +
+				`<span class="hljs-bullet">-</span> <span class="hljs-strong">**a**</span> <span class="hljs-code">` + "`b`" + `</span> [<span class="hljs-string">c</span>](<span class="hljs-link">/d</span>)` + "\n" +
+				`<span class="hljs-code">` + "```" + `</span>` + "\n" + `<span class="hljs-code"># x</span>` + "\n" + `<span class="hljs-code">` + "```" + `</span>`,
+		},
+		{
+			name:     "Diff",
+			language: "diff",
+			code:     "@@ -1 +1 @@\n-a\n+b\n c",
+			want:     `<span class="hljs-meta">@@ -1 +1 @@</span>` + "\n" + `<span class="hljs-deletion">-a</span>` + "\n" + `<span class="hljs-addition">+b</span>` + "\n c",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, HTML(tt.code, tt.language), template.HTML(tt.want))
+		})
+	}
+}
+
+// TestHTMLText tests that highlighting only adds markup around the code of every
+// supported language, by removing the markup and unescaping the result.
+func TestHTMLText(t *testing.T) {
+	code := "#!/bin/x\n/* a */ // b\n# c\n-- d\n<p class=\"e\">'f' `g`</p> @h $i :j 'k 1.5e-3 <?php ?>\n\"\"\"l\nm\"\"\" [[n]]\r\n"
+
+	replacer := strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&", "&#34;", `"`, "&#39;", "'")
+
+	for language := range lexers {
+		t.Run(language, func(t *testing.T) {
+			html := string(HTML(code, language))
+
+			var text strings.Builder
+			for html != "" {
+				start := strings.Index(html, "<")
+				if start < 0 {
+					text.WriteString(html)
+					break
+				}
+				text.WriteString(html[:start])
+				html = html[start+strings.Index(html[start:], ">")+1:]
+			}
+
+			assert.Equal(t, replacer.Replace(text.String()), code)
+		})
+	}
+}
+
+// TestHTMLUnclosedVariables tests that highlighting a long line of unclosed ${
+// variables takes linear time, rather than scanning the rest of the line for each one.
+func TestHTMLUnclosedVariables(t *testing.T) {
+	code := strings.Repeat("${", 100000)
+
+	for _, language := range []string{"bash", "php", "ruby", "perl", "dockerfile", "kotlin"} {
+		t.Run(language, func(t *testing.T) {
+			start := time.Now()
+			html := HTML(code, language)
+
+			assert.Equal(t, strings.Count(string(html), "${"), 100000)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("took %s; want less than a second", elapsed)
+			}
+		})
+	}
+}
+
+// TestCache tests that the Cache highlights code again when it changes, and drops the
+// least recently used entries.
+func TestCache(t *testing.T) {
+	c := NewCache(2)
+
+	assert.Equal(t, c.HTML("1", "nil", "go"), template.HTML(`<span class="hljs-literal">nil</span>`))
+	assert.Equal(t, c.HTML("1", "nil", "plaintext"), template.HTML("nil"))
+	assert.Equal(t, c.HTML("1", "true", "plaintext"), template.HTML("true"))
+	assert.Equal(t, c.Len(), 1)
+
+	c.HTML("2", "x", "plaintext")
+	c.HTML("1", "true", "plaintext")
+	c.HTML("3", "y", "plaintext")
+	assert.Equal(t, c.Len(), 2)
+
+	_, ok := c.entries["2"]
+	assert.Equal(t, ok, false)
+	_, ok = c.entries["1"]
+	assert.Equal(t, ok, true)
+
+	// Code converted by another function is cached the same way.
+	calls := 0
+	upper := func(code, language string) template.HTML {
+		calls++
+		return template.HTML(strings.ToUpper(code))
+	}
+	assert.Equal(t, c.Render("4", "z", "markdown", upper), template.HTML("Z"))
+	assert.Equal(t, c.Render("4", "z", "markdown", upper), template.HTML("Z"))
+	assert.Equal(t, calls, 1)
+}
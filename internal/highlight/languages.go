@@ -0,0 +1,266 @@
+package highlight
+
+// lexers maps the highlight classes of languages, as listed in the language registry,
+// to the function writing code in that language highlighted.
+var lexers = map[string]func(w *writer, code string){
+	"html":       func(w *writer, code string) { lexMarkup(w, code, true) },
+	"xml":        func(w *writer, code string) { lexMarkup(w, code, false) },
+	"css":        func(w *writer, code string) { lexCSS(w, code, false) },
+	"scss":       func(w *writer, code string) { lexCSS(w, code, true) },
+	"javascript": javascript.lex,
+	"typescript": typescript.lex,
+	"json":       json.lex,
+	"php":        php.lex,
+	"python":     python.lex,
+	"go":         golang.lex,
+	"sql":        sql.lex,
+	"bash":       bash.lex,
+	"c":          clang.lex,
+	"cpp":        cpp.lex,
+	"csharp":     csharp.lex,
+	"java":       java.lex,
+	"swift":      swift.lex,
+	"rust":       rust.lex,
+	"ruby":       ruby.lex,
+	"perl":       perl.lex,
+	"lua":        lua.lex,
+	"yaml":       lexYAML,
+	"toml":       lexTOML,
+	"dockerfile": dockerfile.lex,
+	"kotlin":     kotlin.lex,
+	"markdown":   lexMarkdown,
+	"diff":       lexDiff,
+}
+
+func init() {
+	embeddedLexers = map[string]func(w *writer, code string){
+		"script": lexers["javascript"],
+		"style":  lexers["css"],
+	}
+}
+
+// Delimiters shared by several languages.
+var (
+	cComments     = [][2]string{{"/*", "*/"}}
+	cQuotes       = []quote{{delim: `"`, escapes: true}, {delim: "'", escapes: true}}
+	cTitles       = map[string]string{"class": classClass, "struct": classClass, "enum": classClass, "union": classClass}
+	scriptQuotes  = []quote{{delim: "`", escapes: true, multiline: true}, {delim: `"`, escapes: true}, {delim: "'", escapes: true}}
+	scriptTitles  = map[string]string{"function": classFunction, "class": classClass}
+	scriptLiteral = words("true false null undefined NaN Infinity")
+	scriptBuiltIn = words("console window document globalThis Math JSON Object Array String Number Boolean Symbol Promise Map Set WeakMap WeakSet Date RegExp Error TypeError parseInt parseFloat isNaN setTimeout setInterval clearTimeout clearInterval fetch require module exports")
+)
+
+var javascript = &syntax{
+	lineComments:  []string{"//"},
+	blockComments: cComments,
+	quotes:        scriptQuotes,
+	keywords:      words("async await break case catch class const continue debugger default delete do else export extends finally for from function get if import in instanceof let new of return set static super switch this throw try typeof var void while with yield"),
+	literals:      scriptLiteral,
+	builtIns:      scriptBuiltIn,
+	titles:        scriptTitles,
+}
+
+var typescript = &syntax{
+	lineComments:  []string{"//"},
+	blockComments: cComments,
+	quotes:        scriptQuotes,
+	keywords:      words("abstract as async await break case catch class const continue debugger declare default delete do else enum export extends finally for from function get if implements import in infer instanceof interface is keyof let namespace new of private protected public readonly return satisfies set static super switch this throw try type typeof var void while with yield"),
+	literals:      scriptLiteral,
+	types:         words("any bigint boolean never number object string symbol unknown"),
+	builtIns:      scriptBuiltIn,
+	titles:        map[string]string{"function": classFunction, "class": classClass, "interface": classClass, "enum": classClass, "type": classClass},
+	annotations:   true,
+}
+
+var json = &syntax{
+	quotes:   []quote{{delim: `"`, escapes: true}},
+	literals: words("true false null"),
+	keys:     true,
+}
+
+var php = &syntax{
+	lineComments:  []string{"//", "#"},
+	blockComments: cComments,
+	quotes:        []quote{{delim: `"`, escapes: true, multiline: true}, {delim: "'", escapes: true, multiline: true}},
+	metas:         []string{"<?php", "<?=", "?>"},
+	keywords:      words("abstract and as break case catch class clone const continue declare default do echo else elseif empty enddeclare endfor endforeach endif endswitch endwhile enum extends final finally fn for foreach function global goto if implements include include_once instanceof insteadof interface isset list match namespace new or print private protected public readonly require require_once return static switch throw trait try unset use var while xor yield"),
+	literals:      words("true false null TRUE FALSE NULL"),
+	types:         words("array bool callable float int iterable mixed never object string void"),
+	builtIns:      words("count strlen str_replace explode implode array_map array_filter array_keys array_values in_array json_encode json_decode sprintf printf var_dump print_r die exit"),
+	titles:        map[string]string{"function": classFunction, "class": classClass, "interface": classClass, "trait": classClass, "enum": classClass},
+	variables:     "$",
+}
+
+var python = &syntax{
+	lineComments: []string{"#"},
+	quotes: []quote{
+		{delim: `"""`, escapes: true, multiline: true},
+		{delim: "'''", escapes: true, multiline: true},
+		{delim: `"`, escapes: true},
+		{delim: "'", escapes: true},
+	},
+	keywords:    words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda match nonlocal not or pass raise return try while with yield"),
+	literals:    words("True False None"),
+	builtIns:    words("abs all any bool bytes dict enumerate filter float format getattr hasattr input int isinstance len list map max min next object open print range repr reversed round self set sorted str sum super tuple type zip"),
+	titles:      map[string]string{"def": classFunction, "class": classClass},
+	annotations: true,
+}
+
+var golang = &syntax{
+	lineComments:  []string{"//"},
+	blockComments: cComments,
+	quotes:        []quote{{delim: "`", multiline: true}, {delim: `"`, escapes: true}, {delim: "'", escapes: true}},
+	keywords:      words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var"),
+	literals:      words("true false nil iota"),
+	types:         words("any bool byte comparable complex64 complex128 error float32 float64 int int8 int16 int32 int64 rune string uint uint8 uint16 uint32 uint64 uintptr"),
+	builtIns:      words("append cap clear close complex copy delete imag len make max min new panic print println real recover"),
+	titles:        map[string]string{"func": classFunction, "type": classClass},
+}
+
+var sql = &syntax{
+	lineComments:    []string{"--", "#"},
+	blockComments:   cComments,
+	quotes:          []quote{{delim: "'", escapes: true, multiline: true}, {delim: `"`, escapes: true, multiline: true}},
+	keywords:        words("add all alter and as asc auto_increment begin between by cascade case check column commit constraint create cross database default delete desc distinct drop else end exists foreign from full group having if in index inner insert into is join key left like limit not offset on or order outer primary references replace returning right rollback select set table then transaction truncate union unique update using values view when where with"),
+	literals:        words("true false null"),
+	types:           words("bigint binary bit blob bool boolean char date datetime decimal double enum float int integer json longtext mediumtext numeric real serial smallint text time timestamp tinyint uuid varbinary varchar"),
+	builtIns:        words("avg coalesce concat count current_timestamp ifnull length lower max min now round substring sum upper utc_timestamp"),
+	caseInsensitive: true,
+}
+
+var bash = &syntax{
+	lineComments:   []string{"#"},
+	quotes:         []quote{{delim: `"`, escapes: true, multiline: true}, {delim: "'", multiline: true}},
+	keywords:       words("case do done elif else esac fi for function if in local return select then until while export readonly declare unset shift exit"),
+	literals:       words("true false"),
+	builtIns:       words("alias bg cd command echo eval exec fg getopts hash jobs kill printf pwd read set source test trap type ulimit umask wait"),
+	titles:         map[string]string{"function": classFunction},
+	variables:      "$",
+	spacedComments: true,
+}
+
+var clang = &syntax{
+	lineComments:  []string{"//"},
+	blockComments: cComments,
+	quotes:        cQuotes,
+	keywords:      words("auto break case const continue default do else enum extern for goto if inline register restrict return sizeof static struct switch typedef union volatile while"),
+	literals:      words("true false NULL"),
+	types:         words("bool char double float int long short signed unsigned void size_t int8_t int16_t int32_t int64_t uint8_t uint16_t uint32_t uint64_t"),
+	builtIns:      words("printf scanf malloc calloc realloc free memcpy memset strlen strcmp strcpy fopen fclose exit"),
+	titles:        cTitles,
+	preprocessor:  true,
+}
+
+var cpp = &syntax{
+	lineComments:  []string{"//"},
+	blockComments: cComments,
+	quotes:        cQuotes,
+	keywords:      words("alignas alignof auto break case catch class const constexpr const_cast continue decltype default delete do dynamic_cast else enum explicit export extern final for friend goto if inline mutable namespace new noexcept operator override private protected public register reinterpret_cast return sizeof static static_assert static_cast struct switch template this throw try typedef typeid typename union using virtual volatile while"),
+	literals:      words("true false nullptr NULL"),
+	types:         words("bool char char16_t char32_t double float int long short signed unsigned void wchar_t size_t"),
+	builtIns:      words("std cout cin cerr endl string vector map set unordered_map unique_ptr shared_ptr make_unique make_shared printf"),
+	titles:        cTitles,
+	preprocessor:  true,
+}
+
+var csharp = &syntax{
+	lineComments:  []string{"//"},
+	blockComments: cComments,
+	quotes:        cQuotes,
+	keywords:      words("abstract as async await base break case catch checked class const continue default delegate do else enum event explicit extern finally fixed for foreach get goto if implicit in init interface internal is lock namespace new operator out override params private protected public readonly record ref return sealed set sizeof stackalloc static struct switch this throw try typeof unchecked unsafe using var virtual volatile when where while yield"),
+	literals:      words("true false null"),
+	types:         words("bool byte char decimal double dynamic float int long object sbyte short string uint ulong ushort void"),
+	builtIns:      words("Console Math String List Dictionary Task Exception"),
+	titles:        map[string]string{"class": classClass, "interface": classClass, "struct": classClass, "enum": classClass, "record": classClass},
+	preprocessor:  true,
+}
+
+var java = &syntax{
+	lineComments:  []string{"//"},
+	blockComments: cComments,
+	quotes:        []quote{{delim: `"""`, escapes: true, multiline: true}, {delim: `"`, escapes: true}, {delim: "'", escapes: true}},
+	keywords:      words("abstract assert break case catch class const continue default do else enum extends final finally for goto if implements import instanceof interface native new package permits private protected public record return sealed static strictfp super switch synchronized this throw throws transient try var volatile while yield"),
+	literals:      words("true false null"),
+	types:         words("boolean byte char double float int long short void"),
+	builtIns:      words("System String Object Integer Long Double Boolean Math List Map Set ArrayList HashMap Optional Exception"),
+	titles:        map[string]string{"class": classClass, "interface": classClass, "enum": classClass, "record": classClass},
+	annotations:   true,
+}
+
+var swift = &syntax{
+	lineComments:  []string{"//"},
+	blockComments: cComments,
+	quotes:        []quote{{delim: `"""`, escapes: true, multiline: true}, {delim: `"`, escapes: true}},
+	keywords:      words("actor associatedtype async await break case catch class continue default defer deinit do else enum extension fallthrough fileprivate final for func guard if import in init inout internal is let mutating open operator private protocol public repeat rethrows return self Self some static struct subscript super switch throw throws try typealias var weak where while"),
+	literals:      words("true false nil"),
+	types:         words("Any Bool Character Double Float Int Int8 Int16 Int32 Int64 String UInt UInt8 UInt16 UInt32 UInt64 Void"),
+	builtIns:      words("print debugPrint min max abs zip stride Array Dictionary Set Optional"),
+	titles:        map[string]string{"func": classFunction, "class": classClass, "struct": classClass, "enum": classClass, "protocol": classClass, "extension": classClass, "actor": classClass},
+	annotations:   true,
+}
+
+var rust = &syntax{
+	lineComments:  []string{"//"},
+	blockComments: cComments,
+	quotes:        []quote{{delim: `"`, escapes: true, multiline: true}, {delim: "'", escapes: true}},
+	keywords:      words("as async await break const continue crate dyn else enum extern fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while"),
+	literals:      words("true false None Some Ok Err"),
+	types:         words("bool char f32 f64 i8 i16 i32 i64 i128 isize str u8 u16 u32 u64 u128 usize String Vec Option Result Box"),
+	titles:        map[string]string{"fn": classFunction, "struct": classClass, "enum": classClass, "trait": classClass},
+	preprocessor:  true,
+	macros:        true,
+	lifetimes:     true,
+}
+
+var ruby = &syntax{
+	lineComments:  []string{"#"},
+	blockComments: [][2]string{{"=begin", "=end"}},
+	quotes:        []quote{{delim: `"`, escapes: true, multiline: true}, {delim: "'", escapes: true, multiline: true}, {delim: "`", escapes: true, multiline: true}},
+	keywords:      words("alias and begin break case class def do else elsif end ensure for if in module next not or redo rescue retry return self super then undef unless until when while yield require require_relative attr_accessor attr_reader attr_writer include extend private protected public"),
+	literals:      words("true false nil"),
+	builtIns:      words("puts print p raise lambda proc loop"),
+	titles:        map[string]string{"def": classFunction, "class": classClass, "module": classClass},
+	variables:     "@$",
+	symbols:       true,
+}
+
+var perl = &syntax{
+	lineComments: []string{"#"},
+	quotes:       []quote{{delim: `"`, escapes: true, multiline: true}, {delim: "'", escapes: true, multiline: true}},
+	keywords:     words("my our local sub if elsif else unless while until for foreach last next redo return use no require package and or not eq ne lt gt le ge cmp"),
+	builtIns:     words("print printf say die warn open close chomp chop push pop shift unshift split join keys values exists delete defined ref scalar sort map grep"),
+	titles:       map[string]string{"sub": classFunction, "package": classClass},
+	variables:    "$@",
+}
+
+var lua = &syntax{
+	lineComments:  []string{"--"},
+	blockComments: [][2]string{{"--[[", "]]"}},
+	quotes:        []quote{{delim: "[[", close: "]]", multiline: true}, {delim: `"`, escapes: true}, {delim: "'", escapes: true}},
+	keywords:      words("and break do else elseif end for function goto if in local not or repeat return then until while"),
+	literals:      words("true false nil"),
+	builtIns:      words("assert error ipairs next pairs pcall print require select setmetatable getmetatable tonumber tostring type unpack string table math os io coroutine"),
+	titles:        map[string]string{"function": classFunction},
+}
+
+var dockerfile = &syntax{
+	lineComments:    []string{"#"},
+	quotes:          []quote{{delim: `"`, escapes: true}, {delim: "'"}},
+	keywords:        words("from as run cmd label maintainer expose env add copy entrypoint volume user workdir arg onbuild stopsignal healthcheck shell"),
+	variables:       "$",
+	caseInsensitive: true,
+	spacedComments:  true,
+}
+
+var kotlin = &syntax{
+	lineComments:  []string{"//"},
+	blockComments: cComments,
+	quotes:        []quote{{delim: `"""`, multiline: true}, {delim: `"`, escapes: true}, {delim: "'", escapes: true}},
+	keywords:      words("abstract actual annotation as break by catch class companion const constructor continue crossinline data do else enum expect external final finally for fun get if import in infix init inline inner interface internal is lateinit noinline object open operator out override package private protected public reified return sealed set super suspend tailrec this throw try typealias val var vararg when where while"),
+	literals:      words("true false null"),
+	types:         words("Any Boolean Byte Char Double Float Int Long Nothing Short String Unit Array List Map Set MutableList MutableMap"),
+	builtIns:      words("println print listOf mutableListOf mapOf mutableMapOf setOf arrayOf require check error lazy repeat run let also apply with"),
+	titles:        map[string]string{"fun": classFunction, "class": classClass, "interface": classClass, "object": classClass},
+	variables:     "$",
+	annotations:   true,
+}
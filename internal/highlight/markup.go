@@ -0,0 +1,314 @@
+package highlight
+
+import "strings"
+
+// Class names of the tokens of markup languages and stylesheets.
+const (
+	className           = "hljs-name"
+	classAttribute      = "hljs-attribute"
+	classSelectorTag    = "hljs-selector-tag"
+	classSelectorClass  = "hljs-selector-class"
+	classSelectorID     = "hljs-selector-id"
+	classSelectorAttr   = "hljs-selector-attr"
+	classSelectorPseudo = "hljs-selector-pseudo"
+)
+
+// lexMarkup writes HTML or XML code highlighted. In HTML, the content of script and
+// style elements is highlighted as JavaScript and CSS.
+func lexMarkup(w *writer, code string, embedded bool) {
+	for i := 0; i < len(code); {
+		rest := code[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			n := until(rest, 4, "-->")
+			w.token(classComment, rest[:n])
+			i += n
+
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			n := until(rest, 2, ">")
+			w.token(classMeta, rest[:n])
+			i += n
+
+		case rest[0] == '<' && (isIdentStart(next(rest, 1)) || next(rest, 1) == '/' && isIdentStart(next(rest, 2))):
+			n, name := lexTag(w, rest)
+			i += n
+
+			// Highlight the content of script and style elements in their own language
+			lex, ok := embeddedLexers[strings.ToLower(name)]
+			if !ok || !embedded || rest[1] == '/' || strings.HasSuffix(rest[:n], "/>") {
+				continue
+			}
+
+			end := strings.Index(strings.ToLower(code[i:]), "</"+strings.ToLower(name))
+			if end < 0 {
+				end = len(code) - i
+			}
+			lex(w, code[i:i+end])
+			i += end
+
+		default:
+			n := strings.IndexByte(rest[1:], '<') + 1
+			if n == 0 {
+				n = len(rest)
+			}
+			w.plain(rest[:n])
+			i += n
+		}
+	}
+}
+
+// embeddedLexers highlights the content of the HTML elements written in another
+// language. It is filled in by init, as the lexers refer to lexMarkup.
+var embeddedLexers map[string]func(w *writer, code string)
+
+// lexTag writes the opening or closing tag at the start of the code, returning its
+// length and the name of the element.
+func lexTag(w *writer, code string) (int, string) {
+	i := 1
+	if code[i] == '/' {
+		i++
+	}
+	w.plain(code[:i])
+
+	n := tagNameLen(code[i:])
+	name := code[i : i+n]
+	w.token(className, name)
+	i += n
+
+	for i < len(code) {
+		c := code[i]
+
+		switch {
+		case c == '>':
+			w.plain(">")
+			return i + 1, name
+
+		case c == '"' || c == '\'':
+			n := until(code[i:], 1, code[i:i+1])
+			w.token(classString, code[i:i+n])
+			i += n
+
+		case c == '=':
+			// Unquoted attribute values run until the next space or the end of the tag
+			w.plain("=")
+			i++
+			if next(code, i) != '"' && next(code, i) != '\'' {
+				n := strings.IndexAny(code[i:], " \t\r\n>")
+				if n < 0 {
+					n = len(code) - i
+				}
+				w.token(classString, code[i:i+n])
+				i += n
+			}
+
+		case isIdentStart(c):
+			n := tagNameLen(code[i:])
+			w.token(classAttr, code[i:i+n])
+			i += n
+
+		default:
+			w.plain(code[i : i+1])
+			i++
+		}
+	}
+
+	return i, name
+}
+
+// tagNameLen returns the length of the tag or attribute name at the start of the code,
+// which can include dashes, colons and dots, such as in data-id or xlink:href.
+func tagNameLen(code string) int {
+	i := 0
+	for i < len(code) && (isIdent(code[i]) || strings.IndexByte("-:.", code[i]) >= 0) {
+		i++
+	}
+	return i
+}
+
+// until returns the length of the code up to and including the first occurrence of the
+// delimiter after position start, or the length of the code if it doesn't occur.
+func until(code string, start int, delim string) int {
+	end := strings.Index(code[start:], delim)
+	if end < 0 {
+		return len(code)
+	}
+	return start + end + len(delim)
+}
+
+// cssMode is the kind of token expected next in a stylesheet.
+type cssMode int
+
+const (
+	cssStatement cssMode = iota // The start of a rule or declaration.
+	cssSelector
+	cssProperty
+	cssValue
+)
+
+// lexCSS writes CSS or SCSS code highlighted. Rules are told apart from declarations by
+// looking ahead for the brace opening a block, as both can appear in nested blocks.
+func lexCSS(w *writer, code string, scss bool) {
+	depth := 0
+	mode := cssStatement
+
+	for i := 0; i < len(code); {
+		c := code[i]
+		rest := code[i:]
+
+		if isSpace(c) {
+			w.plain(rest[:1])
+			i++
+			continue
+		}
+
+		// Decide what the next rule or declaration starts with
+		if mode == cssStatement {
+			mode = cssSelector
+			if depth > 0 && !opensBlock(rest) {
+				mode = cssProperty
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(rest, "/*"):
+			n := until(rest, 2, "*/")
+			w.token(classComment, rest[:n])
+			i += n
+
+		case scss && strings.HasPrefix(rest, "//") && (i == 0 || code[i-1] != ':'):
+			n := lineEnd(rest)
+			w.token(classComment, rest[:n])
+			i += n
+
+		case c == '"' || c == '\'':
+			n := cssStringLen(rest)
+			w.token(classString, rest[:n])
+			i += n
+
+		case c == '@' && isIdentStart(next(rest, 1)):
+			// The parameters of at-rules, such as media queries, read like values
+			n := 1 + cssIdentLen(rest[1:])
+			w.token(classKeyword, rest[:n])
+			mode = cssValue
+			i += n
+
+		case scss && c == '$' && isIdentStart(next(rest, 1)):
+			n := 1 + cssIdentLen(rest[1:])
+			w.token(classVariable, rest[:n])
+			i += n
+
+		case c == '{' || c == '}' || c == ';':
+			if c == '{' {
+				depth++
+			} else if c == '}' && depth > 0 {
+				depth--
+			}
+			w.plain(rest[:1])
+			mode = cssStatement
+			i++
+
+		case mode == cssProperty && c == ':':
+			w.plain(":")
+			mode = cssValue
+			i++
+
+		case mode == cssProperty && (isIdentStart(c) || c == '-'):
+			n := cssIdentLen(rest)
+			w.token(classAttribute, rest[:n])
+			i += n
+
+		case mode == cssValue && (isNumberStart(rest) || c == '-' && isNumberStart(rest[1:]) || c == '#' && isHex(next(rest, 1))):
+			var n int
+			switch c {
+			case '#':
+				n = 1 + identLen(rest[1:])
+			case '-':
+				n = 1 + numberLen(rest[1:])
+			default:
+				n = numberLen(rest)
+			}
+			if next(rest, n) == '%' {
+				n++
+			}
+			w.token(classNumber, rest[:n])
+			i += n
+
+		case mode == cssSelector && (c == '.' || c == '#') && isIdentStart(next(rest, 1)):
+			n := 1 + cssIdentLen(rest[1:])
+			class := classSelectorClass
+			if c == '#' {
+				class = classSelectorID
+			}
+			w.token(class, rest[:n])
+			i += n
+
+		case mode == cssSelector && c == ':':
+			n := 1
+			if next(rest, 1) == ':' {
+				n++
+			}
+			n += cssIdentLen(rest[n:])
+			w.token(classSelectorPseudo, rest[:n])
+			i += n
+
+		case mode == cssSelector && c == '[':
+			n := until(rest, 1, "]")
+			w.token(classSelectorAttr, rest[:n])
+			i += n
+
+		case mode == cssSelector && isIdentStart(c):
+			n := cssIdentLen(rest)
+			w.token(classSelectorTag, rest[:n])
+			i += n
+
+		case isIdentStart(c) || c == '-':
+			n := cssIdentLen(rest)
+			if n == 0 {
+				n = 1
+			}
+			w.plain(rest[:n])
+			i += n
+
+		default:
+			w.plain(rest[:1])
+			i++
+		}
+	}
+}
+
+// opensBlock reports whether the code up to the end of the current statement opens a
+// block, as rules do, rather than being a declaration.
+func opensBlock(code string) bool {
+	end := strings.IndexAny(code, "{;}")
+	return end >= 0 && code[end] == '{'
+}
+
+// cssStringLen returns the length of the string at the start of the code.
+func cssStringLen(code string) int {
+	for i := 1; i < len(code); i++ {
+		switch code[i] {
+		case '\\':
+			i++
+		case code[0]:
+			return i + 1
+		case '\n':
+			return i
+		}
+	}
+	return len(code)
+}
+
+// cssIdentLen returns the length of the CSS name at the start of the code, which can
+// include dashes.
+func cssIdentLen(code string) int {
+	i := 0
+	for i < len(code) && (isIdent(code[i]) || code[i] == '-') {
+		i++
+	}
+	return i
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,185 @@
+// Package languages provides the registry of the languages snippets can be written in,
+// loaded from a configuration file embedded in the binary. Each language has a key
+// stored with the snippets, a label shown to users, aliases accepted in place of the
+// key, the file extensions and names it is recognised by, the MIME type used when
+// downloading it, and the class used to highlight it.
+package languages
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+//go:embed languages.json
+var config []byte
+
+// Default is the registry of the languages in the embedded configuration file.
+var Default = mustLoad(config)
+
+// Language is a language snippets can be written in.
+type Language struct {
+	Key        string   `json:"key"`
+	Label      string   `json:"label"`
+	Aliases    []string `json:"aliases"`
+	Extensions []string `json:"extensions"`
+	Filenames  []string `json:"filenames"`
+	MIMEType   string   `json:"mime_type"`
+	Highlight  string   `json:"highlight"`
+}
+
+// Registry holds languages in the order of its configuration, indexed by key, alias,
+// file extension and file name.
+type Registry struct {
+	languages   []Language
+	byKey       map[string]*Language
+	byAlias     map[string]*Language
+	byExtension map[string]*Language
+	byFilename  map[string]*Language
+}
+
+// Load returns a registry of the languages in a JSON configuration. Keys and aliases
+// must be unique, and when several languages share a file extension or name, the
+// first one is used to recognise it.
+func Load(data []byte) (*Registry, error) {
+	r := &Registry{
+		byKey:       make(map[string]*Language),
+		byAlias:     make(map[string]*Language),
+		byExtension: make(map[string]*Language),
+		byFilename:  make(map[string]*Language),
+	}
+
+	if err := json.Unmarshal(data, &r.languages); err != nil {
+		return nil, err
+	}
+
+	for i := range r.languages {
+		language := &r.languages[i]
+
+		if language.Key == "" || language.Label == "" {
+			return nil, fmt.Errorf("languages: language %d is missing a key or label", i+1)
+		}
+		if language.MIMEType == "" {
+			language.MIMEType = "text/plain"
+		}
+		if language.Highlight == "" {
+			language.Highlight = language.Key
+		}
+
+		// Keys and aliases share the same namespace, as both can be used to look up a
+		// language.
+		for _, name := range append([]string{language.Key}, language.Aliases...) {
+			name = strings.ToLower(name)
+			if _, ok := r.byAlias[name]; ok {
+				return nil, fmt.Errorf("languages: duplicate key or alias %q", name)
+			}
+			r.byAlias[name] = language
+		}
+		r.byKey[language.Key] = language
+
+		for _, extension := range language.Extensions {
+			extension = strings.ToLower(extension)
+			if _, ok := r.byExtension[extension]; !ok {
+				r.byExtension[extension] = language
+			}
+		}
+		for _, filename := range language.Filenames {
+			filename = strings.ToLower(filename)
+			if _, ok := r.byFilename[filename]; !ok {
+				r.byFilename[filename] = language
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// mustLoad is like Load, but panics if the configuration is invalid.
+func mustLoad(data []byte) *Registry {
+	r, err := Load(data)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// All returns the languages in the order of the configuration.
+func (r *Registry) All() []Language {
+	return r.languages
+}
+
+// Get returns the language with the given key.
+func (r *Registry) Get(key string) (Language, bool) {
+	language, ok := r.byKey[key]
+	if !ok {
+		return Language{}, false
+	}
+	return *language, true
+}
+
+// Has reports whether there is a language with the given key.
+func (r *Registry) Has(key string) bool {
+	_, ok := r.byKey[key]
+	return ok
+}
+
+// Lookup returns the language with the given key or alias, ignoring case.
+func (r *Registry) Lookup(name string) (Language, bool) {
+	language, ok := r.byAlias[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Language{}, false
+	}
+	return *language, true
+}
+
+// ForFilename returns the language of a file given its name, recognised by the whole
+// name first and then by its extension, ignoring case.
+func (r *Registry) ForFilename(filename string) (Language, bool) {
+	base := strings.ToLower(path.Base(strings.ReplaceAll(filename, `\`, "/")))
+
+	if language, ok := r.byFilename[base]; ok {
+		return *language, true
+	}
+	if language, ok := r.byExtension[path.Ext(base)]; ok {
+		return *language, true
+	}
+	return Language{}, false
+}
+
+// Label returns the label of the language with the given key, or "Plain Text" if there
+// is none.
+func (r *Registry) Label(key string) string {
+	if language, ok := r.byKey[key]; ok {
+		return language.Label
+	}
+	return "Plain Text"
+}
+
+// Extension returns the main file extension, including the leading dot, of the language
+// with the given key, or ".txt" if there is none.
+func (r *Registry) Extension(key string) string {
+	if language, ok := r.byKey[key]; ok && len(language.Extensions) > 0 {
+		return language.Extensions[0]
+	}
+	return ".txt"
+}
+
+// MIMEType returns the MIME type of the language with the given key, or "text/plain" if
+// there is none.
+func (r *Registry) MIMEType(key string) string {
+	if language, ok := r.byKey[key]; ok {
+		return language.MIMEType
+	}
+	return "text/plain"
+}
+
+// Highlight returns the class used to highlight the language with the given key, or
+// "plaintext" if there is none.
+func (r *Registry) Highlight(key string) string {
+	if language, ok := r.byKey[key]; ok {
+		return language.Highlight
+	}
+	return "plaintext"
+}
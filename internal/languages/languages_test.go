@@ -0,0 +1,101 @@
+package languages
+
+import (
+	"testing"
+
+	"ssnipp.com/internal/assert"
+)
+
+// TestLoad tests that Load rejects invalid configurations and fills in defaults.
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string // Name of the test case.
+		data    string // Configuration to load.
+		wantErr bool   // Whether loading should fail.
+	}{
+		{
+			name: "Valid",
+			data: `[{"key": "go", "label": "Go", "aliases": ["golang"]}]`,
+		},
+		{
+			name:    "Invalid JSON",
+			data:    `[{"key": "go"`,
+			wantErr: true,
+		},
+		{
+			name:    "Missing label",
+			data:    `[{"key": "go"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "Duplicate key",
+			data:    `[{"key": "go", "label": "Go"}, {"key": "go", "label": "Golang"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "Alias clashing with a key",
+			data:    `[{"key": "go", "label": "Go"}, {"key": "golang", "label": "Golang", "aliases": ["Go"]}]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load([]byte(tt.data))
+			assert.Equal(t, err != nil, tt.wantErr)
+		})
+	}
+
+	r, err := Load([]byte(`[{"key": "go", "label": "Go"}]`))
+	assert.NilError(t, err)
+	assert.Equal(t, r.MIMEType("go"), "text/plain")
+	assert.Equal(t, r.Highlight("go"), "go")
+	assert.Equal(t, r.Extension("go"), ".txt")
+}
+
+// TestDefault tests lookups in the registry of the embedded configuration.
+func TestDefault(t *testing.T) {
+	assert.Equal(t, Default.All()[0].Key, "plaintext")
+	assert.Equal(t, Default.Has("kotlin"), true)
+	assert.Equal(t, Default.Has("js"), false)
+	assert.Equal(t, Default.Label("javascript"), "JavaScript")
+	assert.Equal(t, Default.Label("latin"), "Plain Text")
+	assert.Equal(t, Default.Extension("yaml"), ".yaml")
+	assert.Equal(t, Default.Extension("latin"), ".txt")
+	assert.Equal(t, Default.MIMEType("json"), "application/json")
+	assert.Equal(t, Default.Highlight("shell"), "bash")
+
+	lookups := map[string]string{
+		"JS":     "javascript",
+		" yml ":  "yaml",
+		"golang": "go",
+		"diff":   "diff",
+		"cobol":  "",
+	}
+	for name, want := range lookups {
+		language, _ := Default.Lookup(name)
+		assert.Equal(t, language.Key, want)
+	}
+
+	filenames := map[string]string{
+		"main.go":            "go",
+		"INDEX.HTM":          "html",
+		"scripts/deploy.sh":  "bash",
+		`C:\src\Dockerfile`:  "dockerfile",
+		"app.dockerfile":     "dockerfile",
+		"build.gradle.kts":   "kotlin",
+		"README":             "markdown",
+		"fix.patch":          "diff",
+		"notes":              "",
+		"archive.tar.gz":     "",
+		"config/Cargo.toml":  "toml",
+		".github/ci.yml":     "yaml",
+		"docs/CHANGELOG.MD":  "markdown",
+		"vendor/Gemfile":     "ruby",
+		"component.test.tsx": "typescript",
+	}
+	for filename, want := range filenames {
+		language, _ := Default.ForFilename(filename)
+		assert.Equal(t, language.Key, want)
+	}
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// CollectionModelInterface defines the methods that our CollectionModel must implement.
+// This is useful for testing and mocking purposes.
+type CollectionModelInterface interface {
+	Insert(userID int, name string) (Collection, error)
+	GetBySlug(slug string) (Collection, error)
+	ListByUser(userID int) ([]Collection, error)
+	Delete(id, userID int) error
+	AddSnippet(collectionID, snippetID int) error
+	RemoveSnippet(collectionID, snippetID int) error
+	Snippets(collectionID, userID int) ([]Snippet, error)
+}
+
+// Collection represents a named group of snippets curated by a user, such as "Deploy
+// runbook commands". The fields correspond to the columns in our MySQL collections
+// table, except for UserName, which holds the name of the owner, and Count, which holds
+// the number of snippets in the collection. Like snippets, collections are identified in
+// URLs by a random slug.
+type Collection struct {
+	ID       int
+	Slug     string
+	UserID   int
+	UserName string
+	Name     string
+	Created  time.Time
+	Count    int
+}
+
+// Define a CollectionModel type which wraps a sql.DB connection pool.
+type CollectionModel struct {
+	DB *sql.DB
+}
+
+// Insert creates a new, empty collection owned by the given user.
+func (m *CollectionModel) Insert(userID int, name string) (Collection, error) {
+	collection := Collection{
+		UserID:  userID,
+		Name:    name,
+		Created: time.Now().UTC().Truncate(time.Second),
+	}
+
+	stmt := `INSERT INTO collections (slug, user_id, name, created)
+    VALUES(?, ?, ?, ?)`
+
+	// Try a few random slugs, in case a generated one is already taken.
+	for attempt := 1; ; attempt++ {
+		slug, err := generateSlug()
+		if err != nil {
+			return Collection{}, err
+		}
+
+		result, err := m.DB.Exec(stmt, slug, collection.UserID, collection.Name, collection.Created)
+		if err == nil {
+			id, err := result.LastInsertId()
+			if err != nil {
+				return Collection{}, err
+			}
+
+			collection.ID = int(id)
+			collection.Slug = slug
+
+			return collection, nil
+		}
+
+		// Check if the error is due to a duplicate slug, and retry if so.
+		var mySQLError *mysql.MySQLError
+		if attempt < slugAttempts && errors.As(err, &mySQLError) &&
+			mySQLError.Number == 1062 && strings.Contains(mySQLError.Message, "collections_uc_slug") {
+			continue
+		}
+
+		return Collection{}, err
+	}
+}
+
+// collectionSelect is the start of the SQL statement used to retrieve collections, with
+// the name of their owner and their number of snippets.
+const collectionSelect = `SELECT c.id, c.slug, c.user_id, u.name, c.name, c.created,
+    (SELECT COUNT(*) FROM collection_snippets cs WHERE cs.collection_id = c.id)
+    FROM collections c INNER JOIN users u ON u.id = c.user_id`
+
+// GetBySlug retrieves a specific collection based on its slug.
+func (m *CollectionModel) GetBySlug(slug string) (Collection, error) {
+	var c Collection
+
+	err := m.DB.QueryRow(collectionSelect+" WHERE c.slug = ?", slug).Scan(&c.ID, &c.Slug, &c.UserID, &c.UserName, &c.Name, &c.Created, &c.Count)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Collection{}, ErrNoRecord
+		}
+		return Collection{}, err
+	}
+
+	return c, nil
+}
+
+// ListByUser retrieves the collections owned by the given user, sorted by name.
+func (m *CollectionModel) ListByUser(userID int) ([]Collection, error) {
+	rows, err := m.DB.Query(collectionSelect+" WHERE c.user_id = ? ORDER BY c.name, c.id", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	collections := []Collection{}
+
+	for rows.Next() {
+		var c Collection
+
+		err = rows.Scan(&c.ID, &c.Slug, &c.UserID, &c.UserName, &c.Name, &c.Created, &c.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		collections = append(collections, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+// Delete permanently removes a collection of the given user. The snippets it contains are
+// left untouched. It returns ErrNoRecord if the user has no such collection.
+func (m *CollectionModel) Delete(id, userID int) error {
+	result, err := m.DB.Exec("DELETE FROM collections WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// AddSnippet adds a snippet to a collection. Adding a snippet which is already in the
+// collection does nothing.
+func (m *CollectionModel) AddSnippet(collectionID, snippetID int) error {
+	stmt := `INSERT IGNORE INTO collection_snippets (collection_id, snippet_id, added)
+    VALUES(?, ?, UTC_TIMESTAMP())`
+
+	_, err := m.DB.Exec(stmt, collectionID, snippetID)
+	return err
+}
+
+// RemoveSnippet removes a snippet from a collection. It returns ErrNoRecord if the snippet
+// isn't in the collection.
+func (m *CollectionModel) RemoveSnippet(collectionID, snippetID int) error {
+	result, err := m.DB.Exec("DELETE FROM collection_snippets WHERE collection_id = ? AND snippet_id = ?", collectionID, snippetID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Snippets retrieves the snippets of a collection, in the order they were added. Like
+// SnippetModel.Search, only the snippets the given user may see are returned: their own
+// snippets and the public snippets listed on the explore page.
+func (m *CollectionModel) Snippets(collectionID, userID int) ([]Snippet, error) {
+	stmt := `SELECT COUNT(*) OVER(), s.id, s.slug, s.content, s.created, s.language, s.user_id, u.name, s.expires_at, s.burn_after_read, s.visibility, s.hashed_password, s.encrypted, s.title, s.description, s.filename,
+    (SELECT GROUP_CONCAT(t.tag ORDER BY t.tag) FROM snippet_tags t WHERE t.snippet_id = s.id)
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    INNER JOIN collection_snippets cs ON cs.snippet_id = s.id
+    WHERE cs.collection_id = ? AND s.deleted_at IS NULL
+    AND (s.expires_at IS NULL OR s.expires_at > UTC_TIMESTAMP())
+    AND (s.user_id = ? OR (s.visibility = 'public' AND s.burn_after_read = FALSE AND s.hashed_password IS NULL AND s.encrypted = FALSE))
+    ORDER BY cs.added, s.id`
+
+	// The snippets are scanned like the paginated listings of the snippet model.
+	snippets, _, err := (&SnippetModel{DB: m.DB}).list(stmt, collectionID, userID)
+	return snippets, err
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"ssnipp.com/internal/assert"
+)
+
+// TestCollectionModel tests that snippets can be added to and removed from collections,
+// which only list the snippets each user may see.
+func TestCollectionModel(t *testing.T) {
+	// Skip the test if the "-short" flag is provided when running the test.
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+	m := CollectionModel{db}
+
+	collection, err := m.Insert(1, "Deploy runbook commands")
+	assert.NilError(t, err)
+
+	got, err := m.GetBySlug(collection.Slug)
+	assert.NilError(t, err)
+	assert.Equal(t, got.Name, "Deploy runbook commands")
+	assert.Equal(t, got.UserName, "Alice Jones")
+	assert.Equal(t, got.Count, 0)
+
+	// Add the public snippet created by the setup script, twice.
+	err = m.AddSnippet(collection.ID, 1)
+	assert.NilError(t, err)
+	err = m.AddSnippet(collection.ID, 1)
+	assert.NilError(t, err)
+
+	collections, err := m.ListByUser(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(collections), 1)
+	assert.Equal(t, collections[0].Count, 1)
+
+	snippets, err := m.Snippets(collection.ID, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snippets), 1)
+
+	// Assert that the snippet is hidden from other users once private.
+	_, err = db.Exec("UPDATE snippets SET visibility = 'private' WHERE id = 1")
+	assert.NilError(t, err)
+
+	snippets, err = m.Snippets(collection.ID, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snippets), 0)
+
+	snippets, err = m.Snippets(collection.ID, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snippets), 1)
+
+	err = m.RemoveSnippet(collection.ID, 1)
+	assert.NilError(t, err)
+
+	err = m.RemoveSnippet(collection.ID, 1)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	// Assert that only the owner can delete the collection.
+	err = m.Delete(collection.ID, 2)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	err = m.Delete(collection.ID, 1)
+	assert.NilError(t, err)
+
+	_, err = m.GetBySlug(collection.Slug)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+}
@@ -0,0 +1,88 @@
+package mocks
+
+import (
+	"time"
+
+	"ssnipp.com/internal/models"
+)
+
+// mockCollection is a sample Collection of user 1 used for mocking purposes in tests.
+var mockCollection = models.Collection{
+	ID:       1,
+	Slug:     "cOlL3ct10nAb",
+	UserID:   1,
+	UserName: "Alice Jones",
+	Name:     "Deploy runbook commands",
+	Created:  time.Now(),
+	Count:    1,
+}
+
+type CollectionModel struct{}
+
+// Insert is a mock implementation of the Insert method. It returns a collection with a
+// fixed ID and slug.
+func (m *CollectionModel) Insert(userID int, name string) (models.Collection, error) {
+	return models.Collection{
+		ID:      2,
+		Slug:    "nEwC0ll3ct10",
+		UserID:  userID,
+		Name:    name,
+		Created: time.Now(),
+	}, nil
+}
+
+// GetBySlug is a mock implementation of the GetBySlug method. It returns the
+// mockCollection for its slug, otherwise it returns an ErrNoRecord error.
+func (m *CollectionModel) GetBySlug(slug string) (models.Collection, error) {
+	if slug == mockCollection.Slug {
+		return mockCollection, nil
+	}
+
+	return models.Collection{}, models.ErrNoRecord
+}
+
+// ListByUser is a mock implementation of the ListByUser method. It returns the
+// mockCollection if the user ID is 1, otherwise it returns an empty slice.
+func (m *CollectionModel) ListByUser(userID int) ([]models.Collection, error) {
+	if userID == mockCollection.UserID {
+		return []models.Collection{mockCollection}, nil
+	}
+
+	return []models.Collection{}, nil
+}
+
+// Delete is a mock implementation of the Delete method. It returns nil for the
+// mockCollection of user 1, otherwise it returns an ErrNoRecord error.
+func (m *CollectionModel) Delete(id, userID int) error {
+	if id == mockCollection.ID && userID == mockCollection.UserID {
+		return nil
+	}
+
+	return models.ErrNoRecord
+}
+
+// AddSnippet is a mock implementation of the AddSnippet method. It always succeeds.
+func (m *CollectionModel) AddSnippet(collectionID, snippetID int) error {
+	return nil
+}
+
+// RemoveSnippet is a mock implementation of the RemoveSnippet method. It returns nil if
+// the mockSnippet is removed from the mockCollection, otherwise it returns an ErrNoRecord
+// error.
+func (m *CollectionModel) RemoveSnippet(collectionID, snippetID int) error {
+	if collectionID == mockCollection.ID && snippetID == mockSnippet.ID {
+		return nil
+	}
+
+	return models.ErrNoRecord
+}
+
+// Snippets is a mock implementation of the Snippets method. It returns the mockSnippet for
+// the mockCollection, otherwise it returns an empty slice.
+func (m *CollectionModel) Snippets(collectionID, userID int) ([]models.Snippet, error) {
+	if collectionID == mockCollection.ID {
+		return []models.Snippet{mockSnippet}, nil
+	}
+
+	return []models.Snippet{}, nil
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"strings"
 	"time"
 
 	"ssnipp.com/internal/models"
@@ -8,27 +9,312 @@ import (
 
 // mockSnippet is a sample Snippet used for mocking purposes in tests.
 var mockSnippet = models.Snippet{
-	ID:       1,
-	Content:  "console.log();",
-	Created:  time.Now(),
-	Language: "javascript",
+	ID:          1,
+	Slug:        "aBcD3fGh1jKl",
+	Content:     "console.log();",
+	Created:     time.Now(),
+	Language:    "javascript",
+	UserID:      1,
+	UserName:    "Alice Jones",
+	Visibility:  models.VisibilityPublic,
+	Tags:        []string{"debug", "frontend"},
+	Forks:       1,
+	Title:       "Log to the console",
+	Description: "Prints an empty line, see [the docs](https://developer.mozilla.org/en-US/docs/Web/API/console/log).",
+}
+
+// mockBurnSnippet is a sample burn-after-reading Snippet used for mocking purposes in tests.
+var mockBurnSnippet = models.Snippet{
+	ID:            3,
+	Slug:          "bUrN4fTeR5rD",
+	Content:       "SECRET=hunter2",
+	Created:       time.Now(),
+	Language:      "plaintext",
+	UserID:        1,
+	UserName:      "Alice Jones",
+	BurnAfterRead: true,
+	Visibility:    models.VisibilityUnlisted,
+}
+
+// mockPrivateSnippet is a sample private Snippet used for mocking purposes in tests.
+var mockPrivateSnippet = models.Snippet{
+	ID:         4,
+	Slug:       "pR1v4t3sN1pP",
+	Content:    "DEBUG=true",
+	Created:    time.Now(),
+	Language:   "plaintext",
+	UserID:     1,
+	UserName:   "Alice Jones",
+	Visibility: models.VisibilityPrivate,
+}
+
+// mockProtectedSnippet is a sample password-protected Snippet used for mocking purposes in
+// tests. Its password is "opensesame".
+var mockProtectedSnippet = models.Snippet{
+	ID:             5,
+	Slug:           "pR0t3ct3dSn1",
+	Content:        "DB_PASSWORD=secret",
+	Created:        time.Now(),
+	Language:       "plaintext",
+	UserID:         1,
+	UserName:       "Alice Jones",
+	Visibility:     models.VisibilityUnlisted,
+	HashedPassword: []byte("$2a$04$d7ut.WVTXxcIUNCOp5ecNe2/IoSACkybKzY.bsSAPPrxkl1X6TuaO"),
+}
+
+// mockEncryptedSnippet is a sample encrypted Snippet used for mocking purposes in tests.
+// Its content is ciphertext as produced by the browser.
+var mockEncryptedSnippet = models.Snippet{
+	ID:         6,
+	Slug:       "eNcRyPt3dSn1",
+	Content:    "q0Yy3hX8u4d1nGk2YlN0c2Vjcm0tVGhpc0lzQ2lwaGVydGV4dA",
+	Created:    time.Now(),
+	Language:   "plaintext",
+	UserID:     1,
+	UserName:   "Alice Jones",
+	Visibility: models.VisibilityUnlisted,
+	Encrypted:  true,
+}
+
+// mockMultiFileSnippet is a sample Snippet made of several files used for mocking purposes
+// in tests. It is a fork of mockSnippet.
+var mockMultiFileSnippet = models.Snippet{
+	ID:         7,
+	Slug:       "mUlT1f1l3Sn1",
+	Filename:   "Dockerfile",
+	Content:    "FROM golang:1.22",
+	Created:    time.Now(),
+	Language:   "plaintext",
+	UserID:     1,
+	UserName:   "Alice Jones",
+	Visibility: models.VisibilityUnlisted,
+	ForkedFrom: 1,
+	Files: []models.File{
+		{Filename: "build.sh", Language: "bash", Content: "docker build -t app ."},
+		{Language: "markdown", Content: "Run **build.sh** from the project root."},
+	},
+}
+
+// mockRevisions are the revisions of mockSnippet, most recent first. The latest
+// revision matches the current content of the snippet.
+var mockRevisions = []models.Revision{
+	{
+		SnippetID: 1,
+		Number:    2,
+		Content:   mockSnippet.Content,
+		Created:   mockSnippet.Created,
+		Language:  mockSnippet.Language,
+	},
+	{
+		SnippetID: 1,
+		Number:    1,
+		Content:   "alert();",
+		Created:   mockSnippet.Created,
+		Language:  mockSnippet.Language,
+	},
+}
+
+// mockMultiFileRevisions are the revisions of mockMultiFileSnippet, most recent first.
+// The first revision had a different build script and no notes.
+var mockMultiFileRevisions = []models.Revision{
+	{
+		SnippetID: 7,
+		Number:    2,
+		Filename:  mockMultiFileSnippet.Filename,
+		Content:   mockMultiFileSnippet.Content,
+		Language:  mockMultiFileSnippet.Language,
+		Files:     mockMultiFileSnippet.Files,
+		Created:   mockMultiFileSnippet.Created,
+	},
+	{
+		SnippetID: 7,
+		Number:    1,
+		Filename:  mockMultiFileSnippet.Filename,
+		Content:   mockMultiFileSnippet.Content,
+		Language:  mockMultiFileSnippet.Language,
+		Files:     []models.File{{Filename: "build.sh", Language: "bash", Content: "docker build ."}},
+		Created:   mockMultiFileSnippet.Created,
+	},
 }
 
 // SnippetModel is a mock implementation of the SnippetModel interface.
 type SnippetModel struct{}
 
-// Insert is a mock implementation of the Insert method. It returns a fixed ID and nil error.
-func (m *SnippetModel) Insert(content string, language string) (int, error) {
-	return 2, nil
+// Insert is a mock implementation of the Insert method. It sets a fixed ID and slug
+// and returns a nil error.
+func (m *SnippetModel) Insert(snippet *models.Snippet) error {
+	snippet.ID = 2
+	snippet.Slug = "nEwSn1pP3t0o"
+	return nil
 }
 
 // Get is a mock implementation of the Get method. It returns the mockSnippet if the ID is 1,
-// otherwise it returns an empty Snippet and an ErrNoRecord error.
+// the mockBurnSnippet if the ID is 3, the mockPrivateSnippet if the ID is 4, the
+// mockProtectedSnippet if the ID is 5, the mockEncryptedSnippet if the ID is 6, the
+// mockMultiFileSnippet if the ID is 7, otherwise it returns an empty Snippet and an
+// ErrNoRecord error.
 func (m *SnippetModel) Get(id int) (models.Snippet, error) {
 	switch id {
 	case 1:
 		return mockSnippet, nil
+	case 3:
+		return mockBurnSnippet, nil
+	case 4:
+		return mockPrivateSnippet, nil
+	case 5:
+		return mockProtectedSnippet, nil
+	case 6:
+		return mockEncryptedSnippet, nil
+	case 7:
+		return mockMultiFileSnippet, nil
+	default:
+		return models.Snippet{}, models.ErrNoRecord
+	}
+}
+
+// GetBySlug is a mock implementation of the GetBySlug method. It returns the mocked
+// snippet matching the slug, otherwise it returns an empty Snippet and an ErrNoRecord error.
+func (m *SnippetModel) GetBySlug(slug string) (models.Snippet, error) {
+	switch slug {
+	case mockSnippet.Slug:
+		return mockSnippet, nil
+	case mockBurnSnippet.Slug:
+		return mockBurnSnippet, nil
+	case mockPrivateSnippet.Slug:
+		return mockPrivateSnippet, nil
+	case mockProtectedSnippet.Slug:
+		return mockProtectedSnippet, nil
+	case mockEncryptedSnippet.Slug:
+		return mockEncryptedSnippet, nil
+	case mockMultiFileSnippet.Slug:
+		return mockMultiFileSnippet, nil
 	default:
 		return models.Snippet{}, models.ErrNoRecord
 	}
 }
+
+// ListByUser is a mock implementation of the ListByUser method. It returns the mockSnippet
+// and the mockPrivateSnippet if the user ID is 1, otherwise it returns an empty slice.
+func (m *SnippetModel) ListByUser(userID, limit, offset int) ([]models.Snippet, int, error) {
+	switch userID {
+	case 1:
+		return []models.Snippet{mockSnippet, mockPrivateSnippet}, 2, nil
+	default:
+		return []models.Snippet{}, 0, nil
+	}
+}
+
+// ListPublic is a mock implementation of the ListPublic method. It returns the mockSnippet,
+// which is the only public mocked snippet.
+func (m *SnippetModel) ListPublic(limit, offset int) ([]models.Snippet, int, error) {
+	return []models.Snippet{mockSnippet}, 1, nil
+}
+
+// Search is a mock implementation of the Search method. It returns the mockSnippet and,
+// for user 1, the mockPrivateSnippet and mockMultiFileSnippet, if the content of any of
+// their files contains the query and they match the language.
+func (m *SnippetModel) Search(query, language string, userID, limit, offset int) ([]models.Snippet, int, error) {
+	snippets := []models.Snippet{}
+
+	for _, s := range []models.Snippet{mockSnippet, mockPrivateSnippet, mockMultiFileSnippet} {
+		if s.Visibility != models.VisibilityPublic && s.UserID != userID {
+			continue
+		}
+
+		if language != "" && s.Language != language {
+			continue
+		}
+
+		for _, f := range s.AllFiles() {
+			if strings.Contains(strings.ToLower(f.Content), strings.ToLower(query)) {
+				snippets = append(snippets, s)
+				break
+			}
+		}
+	}
+
+	return snippets, len(snippets), nil
+}
+
+// ListByTag is a mock implementation of the ListByTag method. It returns the mockSnippet
+// for its tags, otherwise it returns an empty slice.
+func (m *SnippetModel) ListByTag(tag string, userID, limit, offset int) ([]models.Snippet, int, error) {
+	for _, t := range mockSnippet.Tags {
+		if t == tag {
+			return []models.Snippet{mockSnippet}, 1, nil
+		}
+	}
+
+	return []models.Snippet{}, 0, nil
+}
+
+// Update is a mock implementation of the Update method. It returns nil if the ID is 1,
+// otherwise it returns an ErrNoRecord error.
+func (m *SnippetModel) Update(snippet *models.Snippet) error {
+	switch snippet.ID {
+	case 1:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+// Revisions is a mock implementation of the Revisions method. It returns the mockRevisions
+// if the snippet ID is 1 and the mockMultiFileRevisions if it is 7, otherwise it returns
+// an empty slice.
+func (m *SnippetModel) Revisions(snippetID int) ([]models.Revision, error) {
+	switch snippetID {
+	case 1:
+		return mockRevisions, nil
+	case 7:
+		return mockMultiFileRevisions, nil
+	default:
+		return []models.Revision{}, nil
+	}
+}
+
+// GetRevision is a mock implementation of the GetRevision method. It returns the
+// matching revision in the revisions returned by Revisions, otherwise it returns an
+// ErrNoRecord error.
+func (m *SnippetModel) GetRevision(snippetID, revision int) (models.Revision, error) {
+	revisions, _ := m.Revisions(snippetID)
+	for _, r := range revisions {
+		if r.Number == revision {
+			return r, nil
+		}
+	}
+
+	return models.Revision{}, models.ErrNoRecord
+}
+
+// Delete is a mock implementation of the Delete method. It returns nil if the ID is 1,
+// otherwise it returns an ErrNoRecord error.
+func (m *SnippetModel) Delete(id int) error {
+	switch id {
+	case 1:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+// Purge is a mock implementation of the Purge method. It never removes any snippet.
+func (m *SnippetModel) Purge(retention time.Duration) (int64, error) {
+	return 0, nil
+}
+
+// Burn is a mock implementation of the Burn method. It returns nil if the ID is 3,
+// otherwise it returns an ErrNoRecord error.
+func (m *SnippetModel) Burn(id int) error {
+	switch id {
+	case 3:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+// DeleteExpired is a mock implementation of the DeleteExpired method. It never removes any snippet.
+func (m *SnippetModel) DeleteExpired() (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,74 @@
+package mocks
+
+import (
+	"time"
+
+	"ssnipp.com/internal/models"
+)
+
+// Plain-text personal API tokens of the mocked user with ID 1, with each scope.
+const (
+	MockReadToken  = "ssnipp_mockReadTokenOfAliceJones000000000"
+	MockWriteToken = "ssnipp_mockWriteTokenOfAliceJones00000000"
+)
+
+// mockToken is a sample Token used for mocking purposes in tests.
+var mockToken = models.Token{
+	ID:      1,
+	UserID:  1,
+	Name:    "Editor plugin",
+	Scope:   models.ScopeRead,
+	Created: time.Now(),
+}
+
+type TokenModel struct{}
+
+// Insert is a mock implementation of the Insert method. It returns a token with a fixed
+// ID and plain-text value.
+func (m *TokenModel) Insert(userID int, name, scope string) (models.Token, error) {
+	return models.Token{
+		ID:        2,
+		UserID:    userID,
+		Name:      name,
+		Scope:     scope,
+		Created:   time.Now(),
+		Plaintext: "ssnipp_nEwT0k3nNewToken00000000000000000",
+	}, nil
+}
+
+// ListByUser is a mock implementation of the ListByUser method. It returns the mockToken
+// if the user ID is 1, otherwise it returns an empty slice.
+func (m *TokenModel) ListByUser(userID int) ([]models.Token, error) {
+	switch userID {
+	case 1:
+		return []models.Token{mockToken}, nil
+	default:
+		return []models.Token{}, nil
+	}
+}
+
+// Revoke is a mock implementation of the Revoke method. It returns nil for the mockToken
+// of user 1, otherwise it returns an ErrNoRecord error.
+func (m *TokenModel) Revoke(id, userID int) error {
+	if id == mockToken.ID && userID == mockToken.UserID {
+		return nil
+	}
+
+	return models.ErrNoRecord
+}
+
+// Authenticate is a mock implementation of the Authenticate method. It returns a token of
+// user 1 for MockReadToken and MockWriteToken, otherwise it returns an ErrInvalidCredentials
+// error.
+func (m *TokenModel) Authenticate(plaintext string) (models.Token, error) {
+	switch plaintext {
+	case MockReadToken:
+		return mockToken, nil
+	case MockWriteToken:
+		token := mockToken
+		token.Scope = models.ScopeWrite
+		return token, nil
+	default:
+		return models.Token{}, models.ErrInvalidCredentials
+	}
+}
@@ -18,21 +18,27 @@ func (m *UserModel) Insert(name, email, password string) error {
 }
 
 // Authenticate is a mock implementation of the Authenticate method. It returns
-// user ID 1 and nil error if the email is "alice@example.com" and the password
-// is "pa$$word". Otherwise, it returns 0 and an ErrInvalidCredentials error.
+// user ID 1 if the email is "alice@example.com" and user ID 2 if the email is
+// "bob@example.com", as long as the password is "pa$$word". Otherwise, it returns
+// 0 and an ErrInvalidCredentials error.
 func (m *UserModel) Authenticate(email, password string) (int, error) {
-	if email == "alice@example.com" && password == "pa$$word" {
-		return 1, nil
+	if password == "pa$$word" {
+		switch email {
+		case "alice@example.com":
+			return 1, nil
+		case "bob@example.com":
+			return 2, nil
+		}
 	}
 
 	return 0, models.ErrInvalidCredentials
 }
 
 // Exists is a mock implementation of the Exists method. It returns true and nil
-// error if the user ID is 1. Otherwise, it returns false and nil error.
+// error if the user ID is 1 or 2. Otherwise, it returns false and nil error.
 func (m *UserModel) Exists(id int) (bool, error) {
 	switch id {
-	case 1:
+	case 1, 2:
 		return true, nil
 	default:
 		return false, nil
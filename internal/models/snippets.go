@@ -1,23 +1,157 @@
 package models
 
 import (
+	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
 	"errors"
+	"slices"
+	"strings"
 	"time"
+	"unicode"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type SnippetModelInterface interface {
-	Insert(content string, language string) (int, error)
+	Insert(snippet *Snippet) error
 	Get(id int) (Snippet, error)
+	GetBySlug(slug string) (Snippet, error)
+	ListByUser(userID, limit, offset int) ([]Snippet, int, error)
+	ListPublic(limit, offset int) ([]Snippet, int, error)
+	ListByTag(tag string, userID, limit, offset int) ([]Snippet, int, error)
+	Search(query, language string, userID, limit, offset int) ([]Snippet, int, error)
+	Update(snippet *Snippet) error
+	Revisions(snippetID int) ([]Revision, error)
+	GetRevision(snippetID, revision int) (Revision, error)
+	Delete(id int) error
+	Purge(retention time.Duration) (int64, error)
+	Burn(id int) error
+	DeleteExpired() (int64, error)
 }
 
+// Visibility levels of a snippet. Public snippets are listed on the explore page,
+// unlisted snippets can only be reached by those who know their URL, and private
+// snippets can only be viewed by their owner.
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+)
+
 // Snippet represents a single code snippet. The fields correspond to the columns
-// in our MySQL snippets table.
+// in our MySQL snippets table, except for UserName, which holds the name of the
+// author and is populated from the users table. Expires is the zero time for
+// snippets which never expire. Slug is the random identifier used in URLs.
+// HashedPassword is nil for snippets which aren't protected by a password. The
+// Content of encrypted snippets is ciphertext, which can only be decrypted in the
+// browser with the key kept in the URL fragment. Tags are stored in the snippet_tags
+// table, and sorted alphabetically. The optional Title and Markdown Description are
+// empty if they weren't given.
+//
+// A snippet is made of one or more files. The main file is described by the Filename,
+// Content and Language fields, and is the one shown in listings. Any additional files
+// are held in Files, stored in the snippet_files table.
+//
+// ForkedFrom is the ID of the snippet this snippet was forked from, or 0 if it isn't a
+// fork, and Forks is the number of snippets forked from it, only retrieved along with
+// single snippets.
 type Snippet struct {
-	ID       int
-	Content  string
-	Created  time.Time
+	ID             int
+	Slug           string
+	Content        string
+	Created        time.Time
+	Language       string
+	UserID         int
+	UserName       string
+	Expires        time.Time
+	BurnAfterRead  bool
+	Visibility     string
+	HashedPassword []byte
+	Encrypted      bool
+	Tags           []string
+	Title          string
+	Description    string
+	Filename       string
+	Files          []File
+	ForkedFrom     int
+	Forks          int
+}
+
+// File represents one of the files of a snippet. The Filename is optional, and
+// files without one are identified by their position in the snippet.
+type File struct {
+	Filename string
 	Language string
+	Content  string
+}
+
+// AllFiles returns the files of the snippet, starting with its main file.
+func (s Snippet) AllFiles() []File {
+	files := []File{{Filename: s.Filename, Language: s.Language, Content: s.Content}}
+	return append(files, s.Files...)
+}
+
+// SetPassword protects the snippet with a password, storing a bcrypt hash of the
+// plain-text password like for users.
+func (s *Snippet) SetPassword(password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return err
+	}
+
+	s.HashedPassword = hashedPassword
+	return nil
+}
+
+// HasPassword reports whether the snippet is protected by a password.
+func (s Snippet) HasPassword() bool {
+	return len(s.HashedPassword) > 0
+}
+
+// MatchesPassword reports whether the plain-text password matches the password of
+// the snippet.
+func (s Snippet) MatchesPassword(password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword(s.HashedPassword, []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Revision represents a saved version of a snippet. The fields correspond to the
+// columns in our MySQL snippet_revisions table, and record the title and files of the
+// snippet like the fields of the same name in Snippet. The additional Files are stored
+// in the snippet_revision_files table, and only retrieved along with single revisions.
+// Revisions are numbered from 1 for each snippet, and a new one is appended every time
+// the title or files of the snippet change.
+type Revision struct {
+	SnippetID int
+	Number    int
+	Title     string
+	Filename  string
+	Content   string
+	Language  string
+	Files     []File
+	Created   time.Time
+}
+
+// AllFiles returns the files of the revision, starting with its main file.
+func (r Revision) AllFiles() []File {
+	files := []File{{Filename: r.Filename, Language: r.Language, Content: r.Content}}
+	return append(files, r.Files...)
+}
+
+// queryer is implemented by sql.DB and sql.Tx, for queries run both on their own and
+// within transactions.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
 }
 
 // Define a SnippetModel type which wraps a sql.DB connection pool.
@@ -25,44 +159,157 @@ type SnippetModel struct {
 	DB *sql.DB
 }
 
-// Insert adds a new snippet to the database and returns the ID of the newly inserted record.
-func (m *SnippetModel) Insert(content string, language string) (int, error) {
-	// SQL statement to insert a new snippet into the database.
-	stmt := `INSERT INTO snippets (content, created, language)
-    VALUES(?, UTC_TIMESTAMP(), ?)`
+// slugAttempts is the number of slugs Insert tries before giving up, in the
+// unlikely event that the generated slugs are already taken.
+const slugAttempts = 5
+
+// generateSlug returns a random, URL-safe slug of 12 characters from crypto/rand.
+// Slugs made only of digits are rejected, so they can't be confused with the
+// sequential IDs used in legacy URLs.
+func generateSlug() (string, error) {
+	b := make([]byte, 9)
 
-	// Execute the SQL statement using the Exec() method. The content and language
-	// parameters will be substituted into the placeholders in the SQL statement.
-	result, err := m.DB.Exec(stmt, content, language)
+	for {
+		_, err := rand.Read(b)
+		if err != nil {
+			return "", err
+		}
+
+		slug := base64.RawURLEncoding.EncodeToString(b)
+		if strings.Trim(slug, "0123456789") != "" {
+			return slug, nil
+		}
+	}
+}
+
+// Insert adds a new snippet to the database, along with its first revision. The
+// Content, Language, UserID, Expires, BurnAfterRead, Visibility, HashedPassword,
+// Encrypted, Tags, Title, Description, Filename, Files and ForkedFrom fields are stored,
+// and the ID and Slug fields are set to the values of the newly inserted record. The
+// snippet never expires if Expires is the zero time.
+func (m *SnippetModel) Insert(snippet *Snippet) error {
+	// Begin a transaction, so the snippet is never stored without its first revision.
+	tx, err := m.DB.Begin()
 	if err != nil {
-		return 0, err
+		return err
+	}
+
+	// Roll back the transaction if it isn't committed. This is a no-op once
+	// Commit() has been called.
+	defer tx.Rollback()
+
+	// SQL statement to insert a new snippet into the database.
+	stmt := `INSERT INTO snippets (slug, content, created, language, user_id, expires_at, burn_after_read, visibility, hashed_password, encrypted, title, description, filename, forked_from)
+    VALUES(?, ?, UTC_TIMESTAMP(), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// Store a NULL expiry for snippets which never expire, a NULL password hash for
+	// snippets which aren't protected by a password, and a NULL reference for snippets
+	// which aren't forks.
+	expiresAt := sql.NullTime{Time: snippet.Expires.UTC(), Valid: !snippet.Expires.IsZero()}
+	hashedPassword := sql.NullString{String: string(snippet.HashedPassword), Valid: snippet.HasPassword()}
+	forkedFrom := sql.NullInt64{Int64: int64(snippet.ForkedFrom), Valid: snippet.ForkedFrom != 0}
+
+	var result sql.Result
+	var slug string
+
+	// Try a few random slugs, in case a generated one is already taken.
+	for attempt := 1; ; attempt++ {
+		slug, err = generateSlug()
+		if err != nil {
+			return err
+		}
+
+		// Execute the SQL statement using the Exec() method. The parameters will be
+		// substituted into the placeholders in the SQL statement.
+		result, err = tx.Exec(stmt, slug, snippet.Content, snippet.Language, snippet.UserID, expiresAt, snippet.BurnAfterRead, snippet.Visibility, hashedPassword, snippet.Encrypted, snippet.Title, snippet.Description, snippet.Filename, forkedFrom)
+		if err == nil {
+			break
+		}
+
+		// Check if the error is due to a duplicate slug, and retry if so.
+		var mySQLError *mysql.MySQLError
+		if attempt < slugAttempts && errors.As(err, &mySQLError) &&
+			mySQLError.Number == 1062 && strings.Contains(mySQLError.Message, "snippets_uc_slug") {
+			continue
+		}
+
+		return err
 	}
 
 	// Get the ID of the newly inserted record.
 	id, err := result.LastInsertId()
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	// Convert the ID from int64 to int and return it.
-	return int(id), nil
+	// Record the initial title and files as the first revision of the snippet.
+	err = insertRevision(tx, int(id), 1, snippet)
+	if err != nil {
+		return err
+	}
+
+	err = insertTags(tx, int(id), snippet.Tags)
+	if err != nil {
+		return err
+	}
+
+	err = insertFiles(tx, int(id), snippet.Files)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	// Convert the ID from int64 to int and set it, along with the slug.
+	snippet.ID = int(id)
+	snippet.Slug = slug
+
+	return nil
 }
 
-// Get retrieves a specific snippet based on its ID.
+// snippetSelect is the start of the SQL statement used to retrieve a single snippet,
+// joined with the users table to get the name of the author, and counting the forks
+// which haven't been deleted. Deleted and expired snippets are excluded, and the
+// statement must be completed with a condition identifying the snippet.
+const snippetSelect = `SELECT s.id, s.slug, s.content, s.created, s.language, s.user_id, u.name, s.expires_at, s.burn_after_read, s.visibility, s.hashed_password, s.encrypted, s.title, s.description, s.filename,
+    (SELECT GROUP_CONCAT(t.tag ORDER BY t.tag) FROM snippet_tags t WHERE t.snippet_id = s.id),
+    s.forked_from, (SELECT COUNT(*) FROM snippets f WHERE f.forked_from = s.id AND f.deleted_at IS NULL)
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    WHERE s.deleted_at IS NULL
+    AND (s.expires_at IS NULL OR s.expires_at > UTC_TIMESTAMP())`
+
+// Get retrieves a specific snippet based on its ID, along with the name of its author
+// and its additional files. Deleted and expired snippets are treated as if they didn't
+// exist.
 func (m *SnippetModel) Get(id int) (Snippet, error) {
-	// SQL statement to retrieve a snippet by its ID.
-	stmt := `SELECT id, content, created, language FROM snippets
-    WHERE id = ?`
+	return m.get(snippetSelect+" AND s.id = ?", id)
+}
+
+// GetBySlug retrieves a specific snippet based on its slug, along with the name of its
+// author and its additional files. Deleted and expired snippets are treated as if they
+// didn't exist.
+func (m *SnippetModel) GetBySlug(slug string) (Snippet, error) {
+	return m.get(snippetSelect+" AND s.slug = ?", slug)
+}
 
-	// Execute the SQL statement using the QueryRow() method, passing in the ID
-	// as the value for the placeholder parameter. This returns a pointer to a sql.Row object.
-	row := m.DB.QueryRow(stmt, id)
+// get runs a statement retrieving a single snippet, scans the result, and retrieves the
+// additional files of the snippet.
+func (m *SnippetModel) get(stmt string, args ...any) (Snippet, error) {
+	// Execute the SQL statement using the QueryRow() method, passing in the
+	// arguments for the placeholder parameters. This returns a pointer to a sql.Row object.
+	row := m.DB.QueryRow(stmt, args...)
 
 	// Initialize a new zeroed Snippet struct.
 	var s Snippet
+	var expires sql.NullTime
+	var tags sql.NullString
+	var forkedFrom sql.NullInt64
 
 	// Copy the values from the sql.Row object to the Snippet struct using the Scan() method.
-	err := row.Scan(&s.ID, &s.Content, &s.Created, &s.Language)
+	err := row.Scan(&s.ID, &s.Slug, &s.Content, &s.Created, &s.Language, &s.UserID, &s.UserName, &expires, &s.BurnAfterRead, &s.Visibility, &s.HashedPassword, &s.Encrypted, &s.Title, &s.Description, &s.Filename, &tags, &forkedFrom, &s.Forks)
 	if err != nil {
 		// If the query returns no rows, return a ErrNoRecord error.
 		if errors.Is(err, sql.ErrNoRows) {
@@ -72,6 +319,523 @@ func (m *SnippetModel) Get(id int) (Snippet, error) {
 		}
 	}
 
+	// A NULL expiry is left as the zero time, and a NULL reference as 0.
+	s.Expires = expires.Time
+	s.Tags = splitTags(tags)
+	s.ForkedFrom = int(forkedFrom.Int64)
+
+	s.Files, err = m.files(s.ID)
+	if err != nil {
+		return Snippet{}, err
+	}
+
 	// Return the filled Snippet struct.
 	return s, nil
 }
+
+// files retrieves the additional files of a snippet, in order. It returns nil for
+// snippets with a single file.
+func (m *SnippetModel) files(snippetID int) ([]File, error) {
+	stmt := `SELECT filename, language, content FROM snippet_files
+    WHERE snippet_id = ?
+    ORDER BY position`
+
+	return queryFiles(m.DB, stmt, snippetID)
+}
+
+// queryFiles runs a statement retrieving files, and scans the result.
+func queryFiles(q queryer, stmt string, args ...any) ([]File, error) {
+	rows, err := q.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var files []File
+
+	for rows.Next() {
+		var f File
+
+		err = rows.Scan(&f.Filename, &f.Language, &f.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, f)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// ListByUser retrieves a page of the snippets owned by the given user, most recent first,
+// whatever their visibility. It also returns the total number of snippets owned by the
+// user, for pagination.
+func (m *SnippetModel) ListByUser(userID, limit, offset int) ([]Snippet, int, error) {
+	// SQL statement to retrieve the snippets of a user, sorted by the indexed created
+	// column. The COUNT(*) OVER() window function returns the total number of
+	// matching rows alongside each row, ignoring LIMIT and OFFSET.
+	stmt := `SELECT COUNT(*) OVER(), s.id, s.slug, s.content, s.created, s.language, s.user_id, u.name, s.expires_at, s.burn_after_read, s.visibility, s.hashed_password, s.encrypted, s.title, s.description, s.filename,
+    (SELECT GROUP_CONCAT(t.tag ORDER BY t.tag) FROM snippet_tags t WHERE t.snippet_id = s.id)
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    WHERE s.user_id = ? AND s.deleted_at IS NULL
+    AND (s.expires_at IS NULL OR s.expires_at > UTC_TIMESTAMP())
+    ORDER BY s.created DESC, s.id DESC
+    LIMIT ? OFFSET ?`
+
+	return m.list(stmt, userID, limit, offset)
+}
+
+// ListPublic retrieves a page of the public snippets of all users, most recent first.
+// Burn-after-reading snippets are left out, as browsing them would burn them, and so are
+// password-protected and encrypted snippets, whose content can't be previewed. It also
+// returns the total number of public snippets, for pagination.
+func (m *SnippetModel) ListPublic(limit, offset int) ([]Snippet, int, error) {
+	stmt := `SELECT COUNT(*) OVER(), s.id, s.slug, s.content, s.created, s.language, s.user_id, u.name, s.expires_at, s.burn_after_read, s.visibility, s.hashed_password, s.encrypted, s.title, s.description, s.filename,
+    (SELECT GROUP_CONCAT(t.tag ORDER BY t.tag) FROM snippet_tags t WHERE t.snippet_id = s.id)
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    WHERE s.visibility = 'public' AND s.burn_after_read = FALSE AND s.hashed_password IS NULL
+    AND s.encrypted = FALSE AND s.deleted_at IS NULL
+    AND (s.expires_at IS NULL OR s.expires_at > UTC_TIMESTAMP())
+    ORDER BY s.created DESC, s.id DESC
+    LIMIT ? OFFSET ?`
+
+	return m.list(stmt, limit, offset)
+}
+
+// Search retrieves a page of the snippets whose content matches the words of the query,
+// in their main file or any additional file, most relevant first, optionally restricted
+// to the language of their main file. Only the snippets the given
+// user may see are searched: their own snippets and the public snippets listed on the
+// explore page. Encrypted snippets are always left out, as their content is ciphertext.
+// It also returns the total number of matching snippets, for pagination.
+func (m *SnippetModel) Search(query, language string, userID, limit, offset int) ([]Snippet, int, error) {
+	terms := fullTextQuery(query)
+	if terms == "" {
+		return []Snippet{}, 0, nil
+	}
+
+	// The FULLTEXT index is queried in boolean mode, where every word must be present,
+	// and the relevance is used to sort the results.
+	stmt := `SELECT COUNT(*) OVER(), s.id, s.slug, s.content, s.created, s.language, s.user_id, u.name, s.expires_at, s.burn_after_read, s.visibility, s.hashed_password, s.encrypted, s.title, s.description, s.filename,
+    (SELECT GROUP_CONCAT(t.tag ORDER BY t.tag) FROM snippet_tags t WHERE t.snippet_id = s.id)
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    WHERE (MATCH(s.content) AGAINST(? IN BOOLEAN MODE)
+        OR s.id IN (SELECT f.snippet_id FROM snippet_files f WHERE MATCH(f.content) AGAINST(? IN BOOLEAN MODE)))
+    AND (? = '' OR s.language = ?)
+    AND s.encrypted = FALSE AND s.deleted_at IS NULL
+    AND (s.expires_at IS NULL OR s.expires_at > UTC_TIMESTAMP())
+    AND (s.user_id = ? OR (s.visibility = 'public' AND s.burn_after_read = FALSE AND s.hashed_password IS NULL))
+    ORDER BY MATCH(s.content) AGAINST(? IN BOOLEAN MODE) DESC, s.created DESC, s.id DESC
+    LIMIT ? OFFSET ?`
+
+	return m.list(stmt, terms, terms, language, language, userID, terms, limit, offset)
+}
+
+// fullTextQuery turns a search query into a boolean mode FULLTEXT search, where each
+// word must be present, possibly as the prefix of a longer word. Any character other
+// than letters, digits and underscores separates words, which also strips the boolean
+// mode operators from the query. It returns an empty string if the query has no words.
+func fullTextQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+
+	for i, word := range words {
+		words[i] = "+" + word + "*"
+	}
+
+	return strings.Join(words, " ")
+}
+
+// ListByTag retrieves a page of the snippets with the given tag, most recent first. Like
+// Search, only the snippets the given user may see are listed: their own snippets and the
+// public snippets listed on the explore page. It also returns the total number of
+// snippets with the tag, for pagination.
+func (m *SnippetModel) ListByTag(tag string, userID, limit, offset int) ([]Snippet, int, error) {
+	stmt := `SELECT COUNT(*) OVER(), s.id, s.slug, s.content, s.created, s.language, s.user_id, u.name, s.expires_at, s.burn_after_read, s.visibility, s.hashed_password, s.encrypted, s.title, s.description, s.filename,
+    (SELECT GROUP_CONCAT(t.tag ORDER BY t.tag) FROM snippet_tags t WHERE t.snippet_id = s.id)
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    INNER JOIN snippet_tags st ON st.snippet_id = s.id
+    WHERE st.tag = ? AND s.deleted_at IS NULL
+    AND (s.expires_at IS NULL OR s.expires_at > UTC_TIMESTAMP())
+    AND (s.user_id = ? OR (s.visibility = 'public' AND s.burn_after_read = FALSE AND s.hashed_password IS NULL AND s.encrypted = FALSE))
+    ORDER BY s.created DESC, s.id DESC
+    LIMIT ? OFFSET ?`
+
+	return m.list(stmt, tag, userID, limit, offset)
+}
+
+// insertTags stores the tags of a snippet within a transaction.
+func insertTags(tx *sql.Tx, snippetID int, tags []string) error {
+	for _, tag := range tags {
+		_, err := tx.Exec("INSERT INTO snippet_tags (snippet_id, tag) VALUES(?, ?)", snippetID, tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertFiles stores the additional files of a snippet within a transaction, numbered
+// from 1 in order.
+func insertFiles(tx *sql.Tx, snippetID int, files []File) error {
+	stmt := `INSERT INTO snippet_files (snippet_id, position, filename, language, content)
+    VALUES(?, ?, ?, ?, ?)`
+
+	for i, f := range files {
+		_, err := tx.Exec(stmt, snippetID, i+1, f.Filename, f.Language, f.Content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertRevision stores the title and files of a snippet as the revision with the given
+// number within a transaction.
+func insertRevision(tx *sql.Tx, snippetID, number int, snippet *Snippet) error {
+	stmt := `INSERT INTO snippet_revisions (snippet_id, revision, title, filename, content, language, created)
+    VALUES(?, ?, ?, ?, ?, ?, UTC_TIMESTAMP())`
+
+	_, err := tx.Exec(stmt, snippetID, number, snippet.Title, snippet.Filename, snippet.Content, snippet.Language)
+	if err != nil {
+		return err
+	}
+
+	stmt = `INSERT INTO snippet_revision_files (snippet_id, revision, position, filename, language, content)
+    VALUES(?, ?, ?, ?, ?, ?)`
+
+	for i, f := range snippet.Files {
+		_, err = tx.Exec(stmt, snippetID, number, i+1, f.Filename, f.Language, f.Content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitTags splits the comma-separated tags retrieved with GROUP_CONCAT. It returns nil
+// for snippets without tags.
+func splitTags(tags sql.NullString) []string {
+	if !tags.Valid || tags.String == "" {
+		return nil
+	}
+
+	return strings.Split(tags.String, ",")
+}
+
+// list runs a statement retrieving a page of snippets along with the total number of
+// matching snippets, and scans the result.
+func (m *SnippetModel) list(stmt string, args ...any) ([]Snippet, int, error) {
+	// Execute the SQL statement using the Query() method, which returns a sql.Rows
+	// resultset containing the result of our query.
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Defer rows.Close() to ensure the sql.Rows resultset is always properly closed.
+	defer rows.Close()
+
+	// Initialize an empty slice to hold the Snippet structs.
+	total := 0
+	snippets := []Snippet{}
+
+	// Iterate through the rows in the resultset, scanning each one into a Snippet struct.
+	for rows.Next() {
+		var s Snippet
+		var expires sql.NullTime
+		var tags sql.NullString
+
+		err = rows.Scan(&total, &s.ID, &s.Slug, &s.Content, &s.Created, &s.Language, &s.UserID, &s.UserName, &expires, &s.BurnAfterRead, &s.Visibility, &s.HashedPassword, &s.Encrypted, &s.Title, &s.Description, &s.Filename, &tags)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		s.Expires = expires.Time
+		s.Tags = splitTags(tags)
+		snippets = append(snippets, s)
+	}
+
+	// Check for any error encountered during the iteration.
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	err = m.listFiles(snippets)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return snippets, total, nil
+}
+
+// listFiles retrieves the additional files of a page of snippets with a single query,
+// and sets them in order.
+func (m *SnippetModel) listFiles(snippets []Snippet) error {
+	if len(snippets) == 0 {
+		return nil
+	}
+
+	positions := make(map[int]int, len(snippets))
+	args := make([]any, len(snippets))
+	for i, s := range snippets {
+		positions[s.ID] = i
+		args[i] = s.ID
+	}
+
+	stmt := `SELECT snippet_id, filename, language, content FROM snippet_files
+    WHERE snippet_id IN (?` + strings.Repeat(", ?", len(snippets)-1) + `)
+    ORDER BY snippet_id, position`
+
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var snippetID int
+		var f File
+
+		err = rows.Scan(&snippetID, &f.Filename, &f.Language, &f.Content)
+		if err != nil {
+			return err
+		}
+
+		s := &snippets[positions[snippetID]]
+		s.Files = append(s.Files, f)
+	}
+
+	return rows.Err()
+}
+
+// Update replaces the content, language, visibility, tags, title, description and files
+// of the snippet identified by the ID field. A new revision with its title and files is
+// appended to its history, unless they are the same as in the latest revision.
+func (m *SnippetModel) Update(snippet *Snippet) error {
+	// Begin a transaction, so the snippet and its history are always in sync.
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	// Lock the snippet row until the transaction ends, so concurrent updates can't
+	// compute the same revision number.
+	var exists int
+	stmt := `SELECT id FROM snippets WHERE id = ? AND deleted_at IS NULL
+    AND (expires_at IS NULL OR expires_at > UTC_TIMESTAMP())
+    FOR UPDATE`
+
+	err = tx.QueryRow(stmt, snippet.ID).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	stmt = "UPDATE snippets SET content = ?, language = ?, visibility = ?, title = ?, description = ?, filename = ? WHERE id = ?"
+
+	_, err = tx.Exec(stmt, snippet.Content, snippet.Language, snippet.Visibility, snippet.Title, snippet.Description, snippet.Filename, snippet.ID)
+	if err != nil {
+		return err
+	}
+
+	// Append the new title and files to the history, numbered after the latest revision,
+	// if any of them changed.
+	stmt = `SELECT snippet_id, revision, title, filename, content, language, created FROM snippet_revisions
+    WHERE snippet_id = ?
+    ORDER BY revision DESC LIMIT 1`
+
+	latest, err := getRevision(tx, stmt, snippet.ID)
+	if err != nil && !errors.Is(err, ErrNoRecord) {
+		return err
+	}
+
+	if latest.Number == 0 || !latest.matches(snippet) {
+		err = insertRevision(tx, snippet.ID, latest.Number+1, snippet)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Replace the tags of the snippet.
+	_, err = tx.Exec("DELETE FROM snippet_tags WHERE snippet_id = ?", snippet.ID)
+	if err != nil {
+		return err
+	}
+
+	err = insertTags(tx, snippet.ID, snippet.Tags)
+	if err != nil {
+		return err
+	}
+
+	// Replace the additional files of the snippet.
+	_, err = tx.Exec("DELETE FROM snippet_files WHERE snippet_id = ?", snippet.ID)
+	if err != nil {
+		return err
+	}
+
+	err = insertFiles(tx, snippet.ID, snippet.Files)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// matches reports whether the revision records the same title and files as the snippet.
+func (r Revision) matches(snippet *Snippet) bool {
+	return r.Title == snippet.Title && slices.Equal(r.AllFiles(), snippet.AllFiles())
+}
+
+// Revisions retrieves the history of a snippet, most recent revision first. The
+// additional files of the revisions aren't retrieved.
+func (m *SnippetModel) Revisions(snippetID int) ([]Revision, error) {
+	stmt := `SELECT snippet_id, revision, title, filename, content, language, created FROM snippet_revisions
+    WHERE snippet_id = ?
+    ORDER BY revision DESC`
+
+	rows, err := m.DB.Query(stmt, snippetID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	revisions := []Revision{}
+
+	for rows.Next() {
+		var r Revision
+
+		err = rows.Scan(&r.SnippetID, &r.Number, &r.Title, &r.Filename, &r.Content, &r.Language, &r.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// GetRevision retrieves a specific revision of a snippet based on its number, along
+// with its additional files.
+func (m *SnippetModel) GetRevision(snippetID, revision int) (Revision, error) {
+	stmt := `SELECT snippet_id, revision, title, filename, content, language, created FROM snippet_revisions
+    WHERE snippet_id = ? AND revision = ?`
+
+	return getRevision(m.DB, stmt, snippetID, revision)
+}
+
+// getRevision runs a statement retrieving a single revision, and retrieves its
+// additional files.
+func getRevision(q queryer, stmt string, args ...any) (Revision, error) {
+	var r Revision
+
+	err := q.QueryRow(stmt, args...).Scan(&r.SnippetID, &r.Number, &r.Title, &r.Filename, &r.Content, &r.Language, &r.Created)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Revision{}, ErrNoRecord
+		}
+		return Revision{}, err
+	}
+
+	stmt = `SELECT filename, language, content FROM snippet_revision_files
+    WHERE snippet_id = ? AND revision = ?
+    ORDER BY position`
+
+	r.Files, err = queryFiles(q, stmt, r.SnippetID, r.Number)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	return r, nil
+}
+
+// Delete marks a snippet as deleted. The snippet is kept in the database until it
+// is purged, but it can no longer be retrieved.
+func (m *SnippetModel) Delete(id int) error {
+	stmt := "UPDATE snippets SET deleted_at = UTC_TIMESTAMP() WHERE id = ? AND deleted_at IS NULL"
+
+	result, err := m.DB.Exec(stmt, id)
+	if err != nil {
+		return err
+	}
+
+	// If no row was updated, the snippet doesn't exist or was already deleted.
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Purge permanently removes the snippets which were deleted longer ago than the
+// retention period, along with their revisions. It returns the number of snippets removed.
+func (m *SnippetModel) Purge(retention time.Duration) (int64, error) {
+	stmt := "DELETE FROM snippets WHERE deleted_at < UTC_TIMESTAMP() - INTERVAL ? SECOND"
+
+	result, err := m.DB.Exec(stmt, int64(retention.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Burn permanently removes a burn-after-reading snippet once it has been read. It returns
+// ErrNoRecord if the snippet was already burned, so it is only ever shown to one reader.
+func (m *SnippetModel) Burn(id int) error {
+	stmt := "DELETE FROM snippets WHERE id = ? AND burn_after_read = TRUE AND deleted_at IS NULL"
+
+	result, err := m.DB.Exec(stmt, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// DeleteExpired permanently removes the snippets which have expired, along with their
+// revisions. It returns the number of snippets removed.
+func (m *SnippetModel) DeleteExpired() (int64, error) {
+	stmt := "DELETE FROM snippets WHERE expires_at <= UTC_TIMESTAMP()"
+
+	result, err := m.DB.Exec(stmt)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
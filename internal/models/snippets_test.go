@@ -0,0 +1,491 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"ssnipp.com/internal/assert"
+)
+
+// TestSnippetModelGet tests the Get method of the SnippetModel.
+func TestSnippetModelGet(t *testing.T) {
+	// Skip the test if the "-short" flag is provided when running the test.
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	// Set up a suite of table-driven tests and expected results.
+	tests := []struct {
+		name         string
+		snippetID    int
+		wantUserName string
+		wantErr      error
+	}{
+		{
+			name:         "Valid ID", // Test case with a valid snippet ID.
+			snippetID:    1,
+			wantUserName: "Alice Jones",
+		},
+		{
+			name:      "Non-existent ID", // Test case with a non-existent snippet ID.
+			snippetID: 2,
+			wantErr:   ErrNoRecord,
+		},
+	}
+
+	// Iterate over the test cases.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Call the newTestDB() helper function to get a connection pool to
+			// our test database.
+			db := newTestDB(t)
+
+			// Create a new instance of the SnippetModel.
+			m := SnippetModel{db}
+
+			// Call the SnippetModel.Get() method.
+			snippet, err := m.Get(tt.snippetID)
+
+			// Assert that the error and the author name match the expected values.
+			assert.Equal(t, errors.Is(err, tt.wantErr), true)
+			assert.Equal(t, snippet.UserName, tt.wantUserName)
+		})
+	}
+}
+
+// TestSnippetModelInsert tests that the Insert method of the SnippetModel generates a slug
+// which can be used to retrieve the snippet.
+func TestSnippetModelInsert(t *testing.T) {
+	// Skip the test if the "-short" flag is provided when running the test.
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+	m := SnippetModel{db}
+
+	snippet := Snippet{Content: "fmt.Println()", Language: "go", UserID: 1, Visibility: VisibilityUnlisted}
+
+	err := m.Insert(&snippet)
+	assert.NilError(t, err)
+	assert.Equal(t, snippet.ID, 2)
+	assert.Equal(t, len(snippet.Slug), 12)
+
+	// Assert that the snippet can be retrieved by its slug.
+	got, err := m.GetBySlug(snippet.Slug)
+	assert.NilError(t, err)
+	assert.Equal(t, got.ID, snippet.ID)
+	assert.Equal(t, got.Content, "fmt.Println()")
+	assert.Equal(t, got.HasPassword(), false)
+
+	// Assert that the password hash of protected snippets is stored.
+	protected := Snippet{Content: "API_KEY=abc", Language: "plaintext", UserID: 1, Visibility: VisibilityUnlisted}
+
+	err = protected.SetPassword("opensesame")
+	assert.NilError(t, err)
+
+	err = m.Insert(&protected)
+	assert.NilError(t, err)
+
+	got, err = m.GetBySlug(protected.Slug)
+	assert.NilError(t, err)
+
+	ok, err := got.MatchesPassword("opensesame")
+	assert.NilError(t, err)
+	assert.Equal(t, ok, true)
+
+	// Assert that unknown slugs return ErrNoRecord.
+	_, err = m.GetBySlug("xxxxxxxxxxxx")
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+}
+
+// TestSnippetModelUpdate tests that the Update method of the SnippetModel appends a revision
+// when the content changes, and changes the visibility of the snippet.
+func TestSnippetModelUpdate(t *testing.T) {
+	// Skip the test if the "-short" flag is provided when running the test.
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+	m := SnippetModel{db}
+
+	// Update the snippet created by the setup script.
+	err := m.Update(&Snippet{ID: 1, Content: "console.log(1);", Language: "javascript", Visibility: VisibilityPrivate})
+	assert.NilError(t, err)
+
+	// Assert that the new content is stored as the second revision.
+	revisions, err := m.Revisions(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(revisions), 2)
+	assert.Equal(t, revisions[0].Number, 2)
+	assert.Equal(t, revisions[0].Content, "console.log(1);")
+
+	// Assert that saving the same content again doesn't append a revision.
+	err = m.Update(&Snippet{ID: 1, Content: "console.log(1);", Language: "javascript", Visibility: VisibilityPublic})
+	assert.NilError(t, err)
+
+	revisions, err = m.Revisions(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(revisions), 2)
+
+	err = m.Update(&Snippet{ID: 1, Content: "console.log(1);", Language: "javascript", Visibility: VisibilityPrivate})
+	assert.NilError(t, err)
+
+	// Assert that the snippet is no longer listed publicly once private.
+	snippets, total, err := m.ListPublic(10, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snippets), 0)
+	assert.Equal(t, total, 0)
+
+	// Assert that updating a non-existent snippet returns ErrNoRecord.
+	err = m.Update(&Snippet{ID: 2, Content: "console.log(1);", Language: "javascript", Visibility: VisibilityUnlisted})
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+}
+
+// TestSnippetModelDelete tests that deleted snippets can no longer be retrieved, and are purged.
+func TestSnippetModelDelete(t *testing.T) {
+	// Skip the test if the "-short" flag is provided when running the test.
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+	m := SnippetModel{db}
+
+	// Delete the snippet created by the setup script.
+	err := m.Delete(1)
+	assert.NilError(t, err)
+
+	// Assert that the snippet is treated as non-existent.
+	_, err = m.Get(1)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	err = m.Delete(1)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	// Assert that the snippet is only purged once the retention period is over.
+	n, err := m.Purge(time.Hour)
+	assert.NilError(t, err)
+	assert.Equal(t, n, int64(0))
+
+	n, err = m.Purge(-time.Hour)
+	assert.NilError(t, err)
+	assert.Equal(t, n, int64(1))
+}
+
+// TestSnippetModelSearch tests that searches only return the snippets the user may see.
+func TestSnippetModelSearch(t *testing.T) {
+	// Skip the test if the "-short" flag is provided when running the test.
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+	m := SnippetModel{db}
+
+	// Insert a private snippet owned by the user with ID 1.
+	private := Snippet{
+		Content:    "console.error(secret);",
+		Created:    time.Now(),
+		Language:   "javascript",
+		UserID:     1,
+		Visibility: VisibilityPrivate,
+	}
+	err := m.Insert(&private)
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name      string
+		query     string
+		language  string
+		userID    int
+		wantTotal int
+	}{
+		{name: "Public snippet", query: "log", userID: 2, wantTotal: 1},
+		{name: "Prefix", query: "cons", userID: 2, wantTotal: 1},
+		{name: "Other language", query: "console", language: "go", userID: 2, wantTotal: 0},
+		{name: "Private snippet of another user", query: "console", userID: 2, wantTotal: 1},
+		{name: "Own private snippet", query: "console", userID: 1, wantTotal: 2},
+		{name: "All words", query: "console secret", userID: 1, wantTotal: 1},
+		{name: "No words", query: "+-*", userID: 1, wantTotal: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippets, total, err := m.Search(tt.query, tt.language, tt.userID, 10, 0)
+			assert.NilError(t, err)
+			assert.Equal(t, total, tt.wantTotal)
+			assert.Equal(t, len(snippets), tt.wantTotal)
+		})
+	}
+}
+
+// TestFullTextQuery tests that search queries are turned into boolean mode searches.
+func TestFullTextQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "Words", query: "console log", want: "+console* +log*"},
+		{name: "Punctuation", query: "console.log();", want: "+console* +log*"},
+		{name: "Operators", query: `-foo +"bar" baz*`, want: "+foo* +bar* +baz*"},
+		{name: "Underscores", query: "DB_PASSWORD", want: "+DB_PASSWORD*"},
+		{name: "Empty", query: "  ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, fullTextQuery(tt.query), tt.want)
+		})
+	}
+}
+
+// TestSnippetModelTags tests that the tags of snippets are stored, replaced and listed.
+func TestSnippetModelTags(t *testing.T) {
+	// Skip the test if the "-short" flag is provided when running the test.
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+	m := SnippetModel{db}
+
+	snippet := Snippet{
+		Content:    "docker compose up -d",
+		Created:    time.Now(),
+		Language:   "bash",
+		UserID:     1,
+		Visibility: VisibilityPublic,
+		Tags:       []string{"docker", "deploy"},
+	}
+	err := m.Insert(&snippet)
+	assert.NilError(t, err)
+
+	// Assert that the tags are retrieved in alphabetical order.
+	got, err := m.Get(snippet.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, strings.Join(got.Tags, ","), "deploy,docker")
+
+	snippets, total, err := m.ListByTag("docker", 2, 10, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, total, 1)
+	assert.Equal(t, snippets[0].ID, snippet.ID)
+
+	// Assert that updating the snippet replaces its tags.
+	snippet.Tags = []string{"compose"}
+	err = m.Update(&snippet)
+	assert.NilError(t, err)
+
+	got, err = m.Get(snippet.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, strings.Join(got.Tags, ","), "compose")
+
+	_, total, err = m.ListByTag("docker", 2, 10, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, total, 0)
+
+	// Assert that snippets without tags have none.
+	got, err = m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(got.Tags), 0)
+}
+
+// TestSnippetModelTitle tests that the title and description of snippets are stored and updated.
+func TestSnippetModelTitle(t *testing.T) {
+	// Skip the test if the "-short" flag is provided when running the test.
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+	m := SnippetModel{db}
+
+	snippet := Snippet{
+		Content:     "docker compose up -d",
+		Language:    "bash",
+		UserID:      1,
+		Visibility:  VisibilityPublic,
+		Title:       "Start the stack",
+		Description: "Runs every service in the **background**.",
+	}
+	err := m.Insert(&snippet)
+	assert.NilError(t, err)
+
+	got, err := m.GetBySlug(snippet.Slug)
+	assert.NilError(t, err)
+	assert.Equal(t, got.Title, "Start the stack")
+	assert.Equal(t, got.Description, "Runs every service in the **background**.")
+
+	// Assert that the title is listed along with the snippet.
+	snippets, _, err := m.ListByUser(1, 10, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, snippets[0].Title, "Start the stack")
+
+	// Assert that updating the snippet can clear its title and description.
+	snippet.Title = ""
+	snippet.Description = ""
+	err = m.Update(&snippet)
+	assert.NilError(t, err)
+
+	got, err = m.Get(snippet.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, got.Title, "")
+	assert.Equal(t, got.Description, "")
+}
+
+// TestSnippetModelFiles tests that the additional files of snippets are stored in order,
+// replaced, and searched.
+func TestSnippetModelFiles(t *testing.T) {
+	// Skip the test if the "-short" flag is provided when running the test.
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+	m := SnippetModel{db}
+
+	snippet := Snippet{
+		Filename:   "Dockerfile",
+		Content:    "FROM golang:1.22",
+		Language:   "plaintext",
+		UserID:     1,
+		Visibility: VisibilityPublic,
+		Files: []File{
+			{Filename: "build.sh", Language: "bash", Content: "docker build -t kubernetes ."},
+			{Language: "plaintext", Content: "Run build.sh from the project root."},
+		},
+	}
+	err := m.Insert(&snippet)
+	assert.NilError(t, err)
+
+	got, err := m.GetBySlug(snippet.Slug)
+	assert.NilError(t, err)
+	assert.Equal(t, got.Filename, "Dockerfile")
+	assert.Equal(t, len(got.Files), 2)
+	assert.Equal(t, got.Files[0].Filename, "build.sh")
+	assert.Equal(t, got.Files[1].Content, "Run build.sh from the project root.")
+	assert.Equal(t, len(got.AllFiles()), 3)
+
+	// Assert that the content of additional files is searched, and that listed snippets
+	// come with their files.
+	snippets, total, err := m.Search("kubernetes", "", 2, 10, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, total, 1)
+	assert.Equal(t, snippets[0].ID, snippet.ID)
+	assert.Equal(t, len(snippets[0].Files), 2)
+	assert.Equal(t, snippets[0].Files[1].Content, "Run build.sh from the project root.")
+
+	snippets, _, err = m.ListByUser(1, 10, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snippets), 2)
+	assert.Equal(t, len(snippets[0].Files), 2)
+	assert.Equal(t, len(snippets[1].Files), 0)
+
+	// Assert that updating the snippet replaces its files.
+	snippet.Files = []File{{Filename: "compose.yml", Language: "plaintext", Content: "services: {}"}}
+	err = m.Update(&snippet)
+	assert.NilError(t, err)
+
+	got, err = m.Get(snippet.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, len(got.Files), 1)
+	assert.Equal(t, got.Files[0].Filename, "compose.yml")
+
+	// Assert that each revision records the files of the snippet when it was saved.
+	revision, err := m.GetRevision(snippet.ID, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, revision.Filename, "Dockerfile")
+	assert.Equal(t, len(revision.Files), 2)
+	assert.Equal(t, revision.Files[0].Content, "docker build -t kubernetes .")
+
+	revision, err = m.GetRevision(snippet.ID, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, revision.Content, "FROM golang:1.22")
+	assert.Equal(t, len(revision.Files), 1)
+	assert.Equal(t, revision.Files[0].Filename, "compose.yml")
+
+	// Assert that snippets with a single file have no additional files.
+	got, err = m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(got.Files), 0)
+}
+
+// TestGenerateSlug tests that generated slugs are URL-safe, never only made of digits,
+// and unique across many calls.
+func TestGenerateSlug(t *testing.T) {
+	rx := regexp.MustCompile(`^[A-Za-z0-9_-]{12}$`)
+	seen := map[string]bool{}
+
+	for range 1000 {
+		slug, err := generateSlug()
+		assert.NilError(t, err)
+
+		assert.Equal(t, rx.MatchString(slug), true)
+		assert.Equal(t, strings.Trim(slug, "0123456789") != "", true)
+		assert.Equal(t, seen[slug], false)
+
+		seen[slug] = true
+	}
+}
+
+// TestSnippetPassword tests that a password set on a snippet is hashed, and only
+// matches the original plain-text password.
+func TestSnippetPassword(t *testing.T) {
+	var s Snippet
+	assert.Equal(t, s.HasPassword(), false)
+
+	err := s.SetPassword("opensesame")
+	assert.NilError(t, err)
+	assert.Equal(t, s.HasPassword(), true)
+	assert.Equal(t, string(s.HashedPassword) != "opensesame", true)
+
+	ok, err := s.MatchesPassword("opensesame")
+	assert.NilError(t, err)
+	assert.Equal(t, ok, true)
+
+	ok, err = s.MatchesPassword("letmein")
+	assert.NilError(t, err)
+	assert.Equal(t, ok, false)
+}
+
+func TestSnippetModelFork(t *testing.T) {
+	// Skip the test if the "-short" flag is provided when running the test.
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+	m := SnippetModel{db}
+
+	fork := Snippet{
+		Content:    "console.log(1);",
+		Language:   "javascript",
+		UserID:     1,
+		Visibility: VisibilityUnlisted,
+		ForkedFrom: 1,
+	}
+	err := m.Insert(&fork)
+	assert.NilError(t, err)
+
+	// Assert that the fork records the original snippet, which counts it.
+	got, err := m.GetBySlug(fork.Slug)
+	assert.NilError(t, err)
+	assert.Equal(t, got.ForkedFrom, 1)
+
+	original, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, original.Forks, 1)
+
+	// Assert that deleted forks aren't counted.
+	err = m.Delete(fork.ID)
+	assert.NilError(t, err)
+
+	original, err = m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, original.Forks, 0)
+}
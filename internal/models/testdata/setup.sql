@@ -1,23 +1,125 @@
+DROP TABLE IF EXISTS collection_snippets;
+DROP TABLE IF EXISTS collections;
+DROP TABLE IF EXISTS snippet_files;
+DROP TABLE IF EXISTS snippet_tags;
+DROP TABLE IF EXISTS tokens;
+DROP TABLE IF EXISTS snippet_revision_files;
+DROP TABLE IF EXISTS snippet_revisions;
 DROP TABLE IF EXISTS snippets;
+DROP TABLE IF EXISTS users;
+
+CREATE TABLE users (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    hashed_password CHAR(60) NOT NULL,
+    created DATETIME NOT NULL
+);
+
+ALTER TABLE users ADD CONSTRAINT users_uc_email UNIQUE (email);
+
 CREATE TABLE snippets (
     id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    slug VARCHAR(16) NOT NULL,
     content MEDIUMTEXT NOT NULL,
     created DATETIME NOT NULL,
-    language VARCHAR(50) NOT NULL
+    language VARCHAR(50) NOT NULL,
+    user_id INTEGER NOT NULL,
+    deleted_at DATETIME NULL,
+    expires_at DATETIME NULL,
+    burn_after_read BOOLEAN NOT NULL DEFAULT FALSE,
+    visibility ENUM('public', 'unlisted', 'private') NOT NULL DEFAULT 'unlisted',
+    hashed_password CHAR(60) NULL,
+    encrypted BOOLEAN NOT NULL DEFAULT FALSE,
+    title VARCHAR(100) NOT NULL DEFAULT '',
+    description VARCHAR(2000) NOT NULL DEFAULT '',
+    filename VARCHAR(100) NOT NULL DEFAULT '',
+    forked_from INTEGER NULL,
+    CONSTRAINT fk_snippets_user_id FOREIGN KEY (user_id) REFERENCES users(id),
+    CONSTRAINT fk_snippets_forked_from FOREIGN KEY (forked_from) REFERENCES snippets(id) ON DELETE SET NULL,
+    CONSTRAINT snippets_uc_slug UNIQUE (slug)
 );
 
 CREATE INDEX idx_snippets_created ON snippets(created);
+CREATE INDEX idx_snippets_deleted_at ON snippets(deleted_at);
+CREATE INDEX idx_snippets_expires_at ON snippets(expires_at);
+CREATE INDEX idx_snippets_visibility_created ON snippets(visibility, created);
+CREATE FULLTEXT INDEX idx_snippets_content ON snippets(content);
 
-DROP TABLE IF EXISTS users;
-CREATE TABLE users (
+CREATE TABLE snippet_revisions (
     id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
-    name VARCHAR(255) NOT NULL,
-    email VARCHAR(255) NOT NULL,
-    hashed_password CHAR(60) NOT NULL,
-    created DATETIME NOT NULL
+    snippet_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    content MEDIUMTEXT NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    created DATETIME NOT NULL,
+    title VARCHAR(100) NOT NULL DEFAULT '',
+    filename VARCHAR(100) NOT NULL DEFAULT '',
+    CONSTRAINT fk_snippet_revisions_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE,
+    CONSTRAINT snippet_revisions_uc_revision UNIQUE (snippet_id, revision)
 );
 
-ALTER TABLE users ADD CONSTRAINT users_uc_email UNIQUE (email);
+CREATE TABLE snippet_revision_files (
+    snippet_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    filename VARCHAR(100) NOT NULL DEFAULT '',
+    language VARCHAR(50) NOT NULL,
+    content MEDIUMTEXT NOT NULL,
+    PRIMARY KEY (snippet_id, revision, position),
+    CONSTRAINT fk_snippet_revision_files_revision FOREIGN KEY (snippet_id, revision) REFERENCES snippet_revisions(snippet_id, revision) ON DELETE CASCADE
+);
+
+CREATE TABLE snippet_tags (
+    snippet_id INTEGER NOT NULL,
+    tag VARCHAR(30) NOT NULL,
+    PRIMARY KEY (snippet_id, tag),
+    CONSTRAINT fk_snippet_tags_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_snippet_tags_tag ON snippet_tags(tag);
+
+CREATE TABLE snippet_files (
+    snippet_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    filename VARCHAR(100) NOT NULL DEFAULT '',
+    language VARCHAR(50) NOT NULL,
+    content MEDIUMTEXT NOT NULL,
+    PRIMARY KEY (snippet_id, position),
+    CONSTRAINT fk_snippet_files_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE FULLTEXT INDEX idx_snippet_files_content ON snippet_files(content);
+
+CREATE TABLE collections (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    slug VARCHAR(16) NOT NULL,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created DATETIME NOT NULL,
+    CONSTRAINT fk_collections_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT collections_uc_slug UNIQUE (slug)
+);
+
+CREATE TABLE collection_snippets (
+    collection_id INTEGER NOT NULL,
+    snippet_id INTEGER NOT NULL,
+    added DATETIME NOT NULL,
+    PRIMARY KEY (collection_id, snippet_id),
+    CONSTRAINT fk_collection_snippets_collection_id FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    CONSTRAINT fk_collection_snippets_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE TABLE tokens (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    hash BINARY(32) NOT NULL,
+    scope ENUM('read', 'write') NOT NULL,
+    created DATETIME NOT NULL,
+    CONSTRAINT fk_tokens_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT tokens_uc_hash UNIQUE (hash)
+);
 
 INSERT INTO users (name, email, hashed_password, created) VALUES (
     'Alice Jones',
@@ -25,3 +127,20 @@ INSERT INTO users (name, email, hashed_password, created) VALUES (
     '$2a$12$NuTjWXm3KKntReFwyBVHyuf/to.HEwTy.eS206TNfkGfr6HzGJSWG',
     '2022-01-01 09:18:24'
 );
+
+INSERT INTO snippets (slug, content, created, language, user_id, visibility) VALUES (
+    'aBcD3fGh1jKl',
+    'console.log();',
+    '2022-01-01 10:00:00',
+    'javascript',
+    1,
+    'public'
+);
+
+INSERT INTO snippet_revisions (snippet_id, revision, content, language, created) VALUES (
+    1,
+    1,
+    'console.log();',
+    'javascript',
+    '2022-01-01 10:00:00'
+);
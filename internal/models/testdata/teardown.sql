@@ -1,3 +1,17 @@
-DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS collection_snippets;
+
+DROP TABLE IF EXISTS collections;
+
+DROP TABLE IF EXISTS snippet_files;
+
+DROP TABLE IF EXISTS snippet_tags;
+
+DROP TABLE IF EXISTS tokens;
+
+DROP TABLE IF EXISTS snippet_revision_files;
+
+DROP TABLE IF EXISTS snippet_revisions;
 
 DROP TABLE IF EXISTS snippets;
+
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+// TokenModelInterface defines the methods that our TokenModel must implement.
+// This is useful for testing and mocking purposes.
+type TokenModelInterface interface {
+	Insert(userID int, name, scope string) (Token, error)
+	ListByUser(userID int) ([]Token, error)
+	Revoke(id, userID int) error
+	Authenticate(plaintext string) (Token, error)
+}
+
+// Scopes of the personal API tokens. Read tokens can only retrieve snippets, while
+// write tokens can also create and delete them.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// tokenPrefix starts every personal API token, to make them easy to recognize, for
+// instance by secret scanners.
+const tokenPrefix = "ssnipp_"
+
+// Token represents a personal API token. The fields correspond to the columns in our
+// MySQL tokens table, except for Plaintext, which is only set when the token is created.
+// Only a SHA-256 hash of the token is stored, so it can't be shown again afterwards.
+type Token struct {
+	ID        int
+	UserID    int
+	Name      string
+	Scope     string
+	Created   time.Time
+	Plaintext string
+}
+
+// Define a TokenModel type which wraps a sql.DB connection pool.
+type TokenModel struct {
+	DB *sql.DB
+}
+
+// hashToken returns the SHA-256 hash of a plain-text token. Tokens are random enough
+// that a fast hash is sufficient, and it allows looking them up by their hash.
+func hashToken(plaintext string) []byte {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hash[:]
+}
+
+// Insert generates a new token for the given user, and stores its hash. The returned
+// token is the only one with the Plaintext field set.
+func (m *TokenModel) Insert(userID int, name, scope string) (Token, error) {
+	b := make([]byte, 24)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return Token{}, err
+	}
+
+	token := Token{
+		UserID:    userID,
+		Name:      name,
+		Scope:     scope,
+		Created:   time.Now().UTC().Truncate(time.Second),
+		Plaintext: tokenPrefix + base64.RawURLEncoding.EncodeToString(b),
+	}
+
+	stmt := `INSERT INTO tokens (user_id, name, hash, scope, created)
+    VALUES(?, ?, ?, ?, ?)`
+
+	result, err := m.DB.Exec(stmt, token.UserID, token.Name, hashToken(token.Plaintext), token.Scope, token.Created)
+	if err != nil {
+		return Token{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return Token{}, err
+	}
+
+	token.ID = int(id)
+
+	return token, nil
+}
+
+// ListByUser retrieves the tokens of the given user, most recent first.
+func (m *TokenModel) ListByUser(userID int) ([]Token, error) {
+	stmt := `SELECT id, user_id, name, scope, created FROM tokens
+    WHERE user_id = ?
+    ORDER BY created DESC, id DESC`
+
+	rows, err := m.DB.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tokens := []Token{}
+
+	for rows.Next() {
+		var t Token
+
+		err = rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Scope, &t.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Revoke permanently removes a token of the given user. It returns ErrNoRecord if the
+// user has no such token.
+func (m *TokenModel) Revoke(id, userID int) error {
+	stmt := "DELETE FROM tokens WHERE id = ? AND user_id = ?"
+
+	result, err := m.DB.Exec(stmt, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Authenticate retrieves the token matching a plain-text token. It returns an
+// ErrInvalidCredentials error if the token doesn't exist or was revoked.
+func (m *TokenModel) Authenticate(plaintext string) (Token, error) {
+	stmt := "SELECT id, user_id, name, scope, created FROM tokens WHERE hash = ?"
+
+	var t Token
+
+	err := m.DB.QueryRow(stmt, hashToken(plaintext)).Scan(&t.ID, &t.UserID, &t.Name, &t.Scope, &t.Created)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Token{}, ErrInvalidCredentials
+		}
+		return Token{}, err
+	}
+
+	return t, nil
+}
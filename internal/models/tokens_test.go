@@ -0,0 +1,46 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"ssnipp.com/internal/assert"
+)
+
+// TestTokenModel tests that tokens can be used to authenticate until they are revoked.
+func TestTokenModel(t *testing.T) {
+	// Skip the test if the "-short" flag is provided when running the test.
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+	m := TokenModel{db}
+
+	token, err := m.Insert(1, "Editor", ScopeRead)
+	assert.NilError(t, err)
+	assert.Equal(t, strings.HasPrefix(token.Plaintext, tokenPrefix), true)
+
+	// Assert that the token authenticates its user with its scope.
+	got, err := m.Authenticate(token.Plaintext)
+	assert.NilError(t, err)
+	assert.Equal(t, got.UserID, 1)
+	assert.Equal(t, got.Scope, ScopeRead)
+	assert.Equal(t, got.Plaintext, "")
+
+	tokens, err := m.ListByUser(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(tokens), 1)
+	assert.Equal(t, tokens[0].Name, "Editor")
+
+	// Assert that only the owner can revoke the token.
+	err = m.Revoke(token.ID, 2)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	err = m.Revoke(token.ID, 1)
+	assert.NilError(t, err)
+
+	_, err = m.Authenticate(token.Plaintext)
+	assert.Equal(t, errors.Is(err, ErrInvalidCredentials), true)
+}
@@ -9,6 +9,15 @@ import (
 // EmailRX is a compiled regular expression for validating email addresses.
 var EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
 
+// TagRX is a compiled regular expression for validating snippet tags: lowercase words
+// of up to 30 letters, digits, hyphens and underscores.
+var TagRX = regexp.MustCompile("^[a-z0-9][a-z0-9_-]{0,29}$")
+
+// FilenameRX is a compiled regular expression for validating the filenames of snippets:
+// letters, digits, dots, hyphens, underscores and plus signs, without path separators.
+// Filenames can start with a single dot, as in ".env", but can't be made only of dots.
+var FilenameRX = regexp.MustCompile(`^\.?[A-Za-z0-9_+-][A-Za-z0-9._+-]*$`)
+
 // Define a new Validator struct which contains a map of validation error messages
 // for our form fields.
 type Validator struct {
@@ -78,3 +87,19 @@ func MinChars(value string, n int) bool {
 func Matches(value string, rx *regexp.Regexp) bool {
 	return rx.MatchString(value)
 }
+
+// MaxItems() returns true if a list contains no more than n items.
+func MaxItems[T any](values []T, n int) bool {
+	return len(values) <= n
+}
+
+// AllMatch() returns true if every value in a list matches a provided compiled
+// regular expression pattern.
+func AllMatch(values []string, rx *regexp.Regexp) bool {
+	for _, v := range values {
+		if !rx.MatchString(v) {
+			return false
+		}
+	}
+	return true
+}
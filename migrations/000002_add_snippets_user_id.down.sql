@@ -0,0 +1,3 @@
+ALTER TABLE snippets DROP FOREIGN KEY fk_snippets_user_id;
+ALTER TABLE snippets DROP COLUMN user_id;
+DELETE FROM users WHERE email = 'legacy-snippets@ssnipp.invalid';
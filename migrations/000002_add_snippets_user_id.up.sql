@@ -0,0 +1,16 @@
+ALTER TABLE snippets ADD COLUMN user_id INTEGER NULL;
+
+-- Snippets created before authorship was recorded need an owner before the
+-- column can be made NOT NULL. If nobody has signed up yet, they are given to a
+-- placeholder account whose well-formed bcrypt hash no password matches.
+INSERT INTO users (name, email, hashed_password, created)
+SELECT 'Legacy snippets', 'legacy-snippets@ssnipp.invalid', CONCAT('$2a$12$', REPEAT('.', 53)), UTC_TIMESTAMP()
+FROM DUAL
+WHERE EXISTS (SELECT 1 FROM snippets) AND NOT EXISTS (SELECT 1 FROM users);
+
+-- Otherwise they are assigned to the first registered user, which is the owner
+-- of the instance.
+UPDATE snippets SET user_id = (SELECT MIN(id) FROM users);
+
+ALTER TABLE snippets MODIFY user_id INTEGER NOT NULL;
+ALTER TABLE snippets ADD CONSTRAINT fk_snippets_user_id FOREIGN KEY (user_id) REFERENCES users(id);
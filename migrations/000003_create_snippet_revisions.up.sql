@@ -0,0 +1,14 @@
+CREATE TABLE snippet_revisions (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    snippet_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    content MEDIUMTEXT NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    created DATETIME NOT NULL,
+    CONSTRAINT fk_snippet_revisions_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE,
+    CONSTRAINT snippet_revisions_uc_revision UNIQUE (snippet_id, revision)
+);
+
+-- Existing snippets start their history with their current content.
+INSERT INTO snippet_revisions (snippet_id, revision, content, language, created)
+SELECT id, 1, content, language, created FROM snippets;
@@ -0,0 +1,4 @@
+DROP INDEX idx_snippets_expires_at ON snippets;
+
+ALTER TABLE snippets DROP COLUMN burn_after_read;
+ALTER TABLE snippets DROP COLUMN expires_at;
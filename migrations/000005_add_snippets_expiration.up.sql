@@ -0,0 +1,4 @@
+ALTER TABLE snippets ADD COLUMN expires_at DATETIME NULL;
+ALTER TABLE snippets ADD COLUMN burn_after_read BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_snippets_expires_at ON snippets(expires_at);
@@ -0,0 +1,2 @@
+ALTER TABLE snippets DROP INDEX snippets_uc_slug;
+ALTER TABLE snippets DROP COLUMN slug;
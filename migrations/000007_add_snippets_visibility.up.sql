@@ -0,0 +1,3 @@
+ALTER TABLE snippets ADD COLUMN visibility ENUM('public', 'unlisted', 'private') NOT NULL DEFAULT 'unlisted';
+
+CREATE INDEX idx_snippets_visibility_created ON snippets(visibility, created);
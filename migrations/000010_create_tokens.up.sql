@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS tokens (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    hash BINARY(32) NOT NULL,
+    scope ENUM('read', 'write') NOT NULL,
+    created DATETIME NOT NULL,
+    CONSTRAINT fk_tokens_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT tokens_uc_hash UNIQUE (hash)
+);
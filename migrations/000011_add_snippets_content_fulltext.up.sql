@@ -0,0 +1 @@
+CREATE FULLTEXT INDEX idx_snippets_content ON snippets(content);
@@ -0,0 +1,2 @@
+ALTER TABLE snippets ADD COLUMN title VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE snippets ADD COLUMN description VARCHAR(2000) NOT NULL DEFAULT '';
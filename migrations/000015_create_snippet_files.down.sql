@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS snippet_files;
+ALTER TABLE snippets DROP COLUMN filename;
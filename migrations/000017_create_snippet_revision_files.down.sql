@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS snippet_revision_files;
+ALTER TABLE snippet_revisions DROP COLUMN filename;
+ALTER TABLE snippet_revisions DROP COLUMN title;
@@ -0,0 +1,27 @@
+ALTER TABLE snippet_revisions ADD COLUMN title VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE snippet_revisions ADD COLUMN filename VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE TABLE snippet_revision_files (
+    snippet_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    filename VARCHAR(100) NOT NULL DEFAULT '',
+    language VARCHAR(50) NOT NULL,
+    content MEDIUMTEXT NOT NULL,
+    PRIMARY KEY (snippet_id, revision, position),
+    CONSTRAINT fk_snippet_revision_files_revision FOREIGN KEY (snippet_id, revision) REFERENCES snippet_revisions(snippet_id, revision) ON DELETE CASCADE
+);
+
+-- The latest revision of existing snippets gets their current title, filename and
+-- additional files. Earlier revisions only recorded the main file.
+UPDATE snippet_revisions r
+INNER JOIN (SELECT snippet_id, MAX(revision) AS revision FROM snippet_revisions GROUP BY snippet_id) latest
+    ON latest.snippet_id = r.snippet_id AND latest.revision = r.revision
+INNER JOIN snippets s ON s.id = r.snippet_id
+SET r.title = s.title, r.filename = s.filename;
+
+INSERT INTO snippet_revision_files (snippet_id, revision, position, filename, language, content)
+SELECT f.snippet_id, latest.revision, f.position, f.filename, f.language, f.content
+FROM snippet_files f
+INNER JOIN (SELECT snippet_id, MAX(revision) AS revision FROM snippet_revisions GROUP BY snippet_id) latest
+    ON latest.snippet_id = f.snippet_id;
@@ -0,0 +1,8 @@
+// Tell the page embedding the snippet how tall it is, so that it can fit the iframe to
+// the snippet.
+const postHeight = () => {
+  window.parent.postMessage({ ssnipp: "embed", height: document.documentElement.scrollHeight }, "*");
+}
+
+window.addEventListener("load", postHeight);
+window.addEventListener("resize", postHeight);
@@ -1,5 +1,152 @@
-// Initialize highlight.js
-hljs.highlightAll();
+// Encrypted snippets are encrypted in the browser with AES-GCM. The key is kept in the
+// URL fragment, which browsers never send to the server, so the server only ever sees
+// the ciphertext: the base64url encoding of the IV followed by the encrypted content.
+const ivLength = 12;
+
+const toBase64Url = (bytes) => {
+  let binary = "";
+  for (const byte of bytes) {
+    binary += String.fromCharCode(byte);
+  }
+  return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+const fromBase64Url = (text) => {
+  const base64 = text.replace(/-/g, "+").replace(/_/g, "/");
+  return Uint8Array.from(atob(base64), (c) => c.charCodeAt(0));
+}
+
+// Generate a new key, returning it along with its encoding for the URL fragment
+const generateKey = async () => {
+  const key = await crypto.subtle.generateKey({ name: "AES-GCM", length: 256 }, true, ["encrypt", "decrypt"]);
+  const raw = await crypto.subtle.exportKey("raw", key);
+  return { key, fragment: toBase64Url(new Uint8Array(raw)) };
+}
+
+// Import the key from the URL fragment
+const importKey = (fragment) => {
+  return crypto.subtle.importKey("raw", fromBase64Url(fragment), "AES-GCM", false, ["encrypt", "decrypt"]);
+}
+
+const encryptContent = async (key, plaintext) => {
+  const iv = crypto.getRandomValues(new Uint8Array(ivLength));
+  const ciphertext = await crypto.subtle.encrypt({ name: "AES-GCM", iv }, key, new TextEncoder().encode(plaintext));
+
+  const data = new Uint8Array(iv.length + ciphertext.byteLength);
+  data.set(iv);
+  data.set(new Uint8Array(ciphertext), iv.length);
+  return toBase64Url(data);
+}
+
+const decryptContent = async (key, content) => {
+  const data = fromBase64Url(content.trim());
+  const plaintext = await crypto.subtle.decrypt({ name: "AES-GCM", iv: data.slice(0, ivLength) }, key, data.slice(ivLength));
+  return new TextDecoder().decode(plaintext);
+}
+
+const keyFragment = () => window.location.hash.slice(1);
+
+const showCryptoError = () => {
+  const error = document.getElementById("crypto-error");
+  error.classList.remove("hidden");
+  error.classList.add("block");
+}
+
+// Encrypt the content of the files of the snippet form before submitting it. The key is
+// added to the fragment of the form action, which browsers carry over to the redirect to
+// the snippet.
+const snippetForm = document.getElementById("snippet-form");
+
+if (snippetForm) {
+  const contents = () => snippetForm.querySelectorAll("textarea[data-file-content]");
+  const encryptCheckbox = document.getElementById("encrypted");
+  const isEncrypted = () => snippetForm.hasAttribute("data-encrypted") || (encryptCheckbox && encryptCheckbox.checked);
+
+  // Decrypt the content of encrypted snippets being edited
+  if (snippetForm.hasAttribute("data-encrypted") && document.getElementById("content").value !== "") {
+    importKey(keyFragment())
+      .then((key) => Promise.all(Array.from(contents(), async (content) => {
+        content.value = await decryptContent(key, content.value);
+      })))
+      .catch(() => {
+        contents().forEach((content) => { content.value = ""; });
+        showCryptoError();
+      });
+  }
+
+  snippetForm.addEventListener("submit", async (event) => {
+    // Blank content is left for the server to reject
+    if (!isEncrypted() || Array.from(contents()).some((content) => content.value.trim() === "")) {
+      return;
+    }
+
+    event.preventDefault();
+
+    try {
+      const { key, fragment } = snippetForm.hasAttribute("data-encrypted")
+        ? { key: await importKey(keyFragment()), fragment: keyFragment() }
+        : await generateKey();
+
+      for (const content of contents()) {
+        content.value = await encryptContent(key, content.value);
+      }
+      snippetForm.action = snippetForm.action.split("#")[0] + "#" + fragment;
+      snippetForm.submit();
+    } catch (err) {
+      showCryptoError();
+    }
+  });
+}
+
+// Add and remove the additional files of the snippet form. The fields of the files are
+// renumbered after every change, so that the server receives a list without gaps.
+const fileList = document.getElementById("files");
+const fileTemplate = document.getElementById("file-template");
+
+const renumberFiles = () => {
+  fileList.querySelectorAll("[data-file]").forEach((file, i) => {
+    file.querySelectorAll("[data-field]").forEach((field) => {
+      field.name = `files[${i}].${field.dataset.field}`;
+    });
+  });
+}
+
+if (fileList && fileTemplate) {
+  document.getElementById("add-file").addEventListener("click", () => {
+    fileList.appendChild(fileTemplate.content.cloneNode(true));
+    renumberFiles();
+  });
+
+  fileList.addEventListener("click", (event) => {
+    const removeButton = event.target.closest("[data-remove-file]");
+    if (removeButton) {
+      removeButton.closest("[data-file]").remove();
+      renumberFiles();
+    }
+  });
+}
+
+// Decrypt the files of encrypted snippets. Other snippets are highlighted on the server,
+// but encrypted ones can only be highlighted once decrypted.
+const snippet = document.getElementById("snippet");
+const snippetFiles = document.querySelectorAll("code[data-snippet-file]");
+
+if (snippet && snippet.hasAttribute("data-encrypted")) {
+  importKey(keyFragment())
+    .then((key) => Promise.all(Array.from(snippetFiles, async (file) => {
+      file.textContent = await decryptContent(key, file.textContent);
+      hljs.highlightElement(file);
+    })))
+    .catch(() => {
+      snippetFiles.forEach((file) => { file.textContent = ""; });
+      showCryptoError();
+    });
+
+  // Keep the key in the links which need it, such as the edit link
+  document.querySelectorAll("a[data-keep-fragment]").forEach((link) => {
+    link.href += window.location.hash;
+  });
+}
 
 // Copy URL to clipboard
 const copyUrl = async () => {
@@ -12,19 +159,116 @@ const copyUrl = async () => {
 }
 
 // Copy to clipboard
-let text = (document.getElementById("snippet").innerText);
-
 const copyContent = async () => {
   try {
-    await navigator.clipboard.writeText(text);
+    await navigator.clipboard.writeText(snippet.innerText);
     showCopyMessage();
   } catch (err) {
     showCopyMessage("error");
   }
 }
-  
-document.getElementById("copy-button").addEventListener("click", copyContent);
-document.getElementById("copy-url").addEventListener("click", copyUrl);
+
+const copyButton = document.getElementById("copy-button");
+const copyUrlButton = document.getElementById("copy-url");
+
+if (copyButton) {
+  copyButton.addEventListener("click", copyContent);
+}
+
+if (copyUrlButton) {
+  copyUrlButton.addEventListener("click", copyUrl);
+}
+
+// Copy one of the files of multi-file snippets
+document.querySelectorAll("[data-copy-file]").forEach((button) => {
+  button.addEventListener("click", async () => {
+    try {
+      await navigator.clipboard.writeText(snippetFiles[button.dataset.copyFile].innerText);
+      showCopyMessage();
+    } catch (err) {
+      showCopyMessage("error");
+    }
+  });
+});
+
+// Switch the Markdown files of snippets between their rendered HTML and their source
+document.querySelectorAll("[data-toggle-source]").forEach((button) => {
+  const rendered = document.querySelector(`[data-rendered-file="${button.dataset.toggleSource}"]`);
+  const source = document.querySelector(`[data-source-file="${button.dataset.toggleSource}"]`);
+
+  button.addEventListener("click", () => {
+    const showSource = !source.classList.toggle("hidden");
+    rendered.classList.toggle("hidden", showSource);
+    button.textContent = showSource ? "Preview" : "Source";
+  });
+});
+
+// Highlight the lines linked to in the URL fragment, such as #L12 or #L12-L30 in the main
+// file and #F2-L12 in the others. Shift-clicking a line number extends the selection to it.
+const lineAnchorRX = /^#((?:F\d+-)?)L(\d+)(?:-L(\d+))?$/;
+
+const highlightLines = () => {
+  document.querySelectorAll(".line-highlight").forEach((line) => line.classList.remove("line-highlight"));
+
+  const match = lineAnchorRX.exec(window.location.hash);
+  const code = match && document.querySelector(`code[data-line-prefix="${match[1]}"]`);
+  if (!code) {
+    return;
+  }
+
+  // Show the source of Markdown files, which are rendered by default
+  const source = code.closest("pre");
+  if (source.classList.contains("hidden")) {
+    document.querySelector(`[data-toggle-source="${source.dataset.sourceFile}"]`).click();
+  }
+
+  // Stop at the last line, however far the range in the URL goes
+  const start = Number(match[2]);
+  const end = Math.min(Math.max(start, Number(match[3] || start)), code.querySelectorAll("[data-line]").length);
+  for (let number = start; number <= end; number++) {
+    const line = code.querySelector(`[data-line="${number}"]`);
+    const anchor = document.getElementById(`${match[1]}L${number}`);
+    if (line && anchor) {
+      line.classList.add("line-highlight");
+      anchor.classList.add("line-highlight");
+    }
+  }
+
+  const first = document.getElementById(`${match[1]}L${start}`);
+  if (first) {
+    first.scrollIntoView({ block: "center" });
+  }
+}
+
+document.querySelectorAll("a[data-line-number]").forEach((link) => {
+  link.addEventListener("click", (event) => {
+    const current = lineAnchorRX.exec(window.location.hash);
+    const target = lineAnchorRX.exec(link.hash);
+    if (!event.shiftKey || !current || current[1] !== target[1]) {
+      return;
+    }
+
+    event.preventDefault();
+    const [start, end] = [Number(current[2]), Number(target[2])].sort((a, b) => a - b);
+    window.location.hash = `${target[1]}L${start}-L${end}`;
+  });
+});
+
+if (document.querySelector("code[data-line-prefix]")) {
+  window.addEventListener("hashchange", highlightLines);
+  highlightLines();
+}
+
+// Ask for confirmation before deleting a snippet, or what the form says it deletes
+const deleteForm = document.getElementById("delete-form");
+
+if (deleteForm) {
+  deleteForm.addEventListener("submit", (event) => {
+    if (!confirm(deleteForm.dataset.confirm || "Are you sure you want to delete this snippet?")) {
+      event.preventDefault();
+    }
+  });
+}
 
 // Show copy to cliboard message
 showCopyMessage = (messageType = "success") => {
@@ -34,7 +278,7 @@ showCopyMessage = (messageType = "success") => {
   copyMessage.innerHTML = messageType === "success" ? "Copied to clipboard!" : "Failed to copy to clipboard!";
   copyMessage.id = "copy-message";
   copyMessage.className = messageType === "success" ? "fixed bottom-1 right-1 p-4 bg-green-100 text-green-500 rounded text-sm" : "fixed bottom-1 right-1 p-4 bg-red-100 text-red-500 rounded text-sm";
-  
+
   document.body.appendChild(copyMessage);
 
   document.getElementById("copy-message").style.display = "block";
@@ -44,4 +288,4 @@ showCopyMessage = (messageType = "success") => {
     document.getElementById("copy-message").remove();
   }, 2000);
 }
-  
+